@@ -0,0 +1,155 @@
+package arith
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrDivideByZero = errors.New("arith: division by zero")
+	ErrOverflow     = errors.New("arith: overflow")
+	ErrNaN          = errors.New("arith: not a number")
+)
+
+/*
+Policy is what happens when an operation fails. The three division helpers of main are the three policies:
+divideWithTwoReturnTypes returns an error, divideWithPanic panics and divide gives the IEEE result (+Inf)
+*/
+type Policy int
+
+const (
+	// ReturnError returns ErrDivideByZero, ErrOverflow or ErrNaN (wrapped, use errors.Is)
+	ReturnError Policy = iota
+	// Panic panics with the same error
+	Panic
+	/*
+		IEEE ignores the error and keeps the result the hardware gives: Inf and NaN for floats,
+		the wrapped around value for integers and 0 for an integer division by zero
+	*/
+	IEEE
+)
+
+// Handle applies the policy to the error of a checked operation
+func (p Policy) Handle(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch p {
+	case Panic:
+		panic(err)
+	case IEEE:
+		return nil
+	}
+	return err
+}
+
+// Divide divides two floats under the policy
+func (p Policy) Divide(a, b float64) (float64, error) {
+	result, err := DivideFloat64(a, b)
+	return result, p.Handle(err)
+}
+
+/*
+DivideFloat64 returns the IEEE result together with an error: ErrNaN when an operand or the result is NaN,
+ErrDivideByZero when b is zero and ErrOverflow when finite operands give an infinite result
+*/
+func DivideFloat64(a, b float64) (float64, error) {
+	result := a / b
+	switch {
+	case math.IsNaN(a) || math.IsNaN(b):
+		return result, fmt.Errorf("%w: %v / %v", ErrNaN, a, b)
+	case b == 0:
+		return result, fmt.Errorf("%w: %v / %v", ErrDivideByZero, a, b)
+	case math.IsNaN(result): //Inf / Inf
+		return result, fmt.Errorf("%w: %v / %v", ErrNaN, a, b)
+	case math.IsInf(result, 0) && !math.IsInf(a, 0):
+		return result, fmt.Errorf("%w: %v / %v", ErrOverflow, a, b)
+	}
+	return result, nil
+}
+
+// CheckFloat64 turns NaN and infinities, results of an earlier computation, into errors
+func CheckFloat64(x float64) error {
+	if math.IsNaN(x) {
+		return ErrNaN
+	}
+	if math.IsInf(x, 0) {
+		return fmt.Errorf("%w: %v", ErrOverflow, x)
+	}
+	return nil
+}
+
+const (
+	maxInt  = int64(^uint(0) >> 1)
+	minInt  = -maxInt - 1
+	maxUint = uint64(^uint(0))
+)
+
+/*
+The checked integer operations of checked.go all come down to the four below, on int64 or uint64 with the
+bounds of the real type. They return the wrapped result (what Go gives without checks) and the error
+*/
+
+func addSigned(a, b, min, max int64) (int64, error) {
+	if (b > 0 && a > max-b) || (b < 0 && a < min-b) {
+		return a + b, fmt.Errorf("%w: %d + %d", ErrOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+func subSigned(a, b, min, max int64) (int64, error) {
+	if (b < 0 && a > max+b) || (b > 0 && a < min+b) {
+		return a - b, fmt.Errorf("%w: %d - %d", ErrOverflow, a, b)
+	}
+	return a - b, nil
+}
+
+func mulSigned(a, b, min, max int64) (int64, error) {
+	result := a * b
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	if result/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || result < min || result > max {
+		return result, fmt.Errorf("%w: %d * %d", ErrOverflow, a, b)
+	}
+	return result, nil
+}
+
+func divSigned(a, b, min int64) (int64, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("%w: %d / %d", ErrDivideByZero, a, b)
+	}
+	if a == min && b == -1 {
+		return min, fmt.Errorf("%w: %d / %d", ErrOverflow, a, b)
+	}
+	return a / b, nil
+}
+
+func addUnsigned(a, b, max uint64) (uint64, error) {
+	if a > max-b {
+		return a + b, fmt.Errorf("%w: %d + %d", ErrOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+func subUnsigned(a, b uint64) (uint64, error) {
+	if a < b {
+		return a - b, fmt.Errorf("%w: %d - %d", ErrOverflow, a, b)
+	}
+	return a - b, nil
+}
+
+func mulUnsigned(a, b, max uint64) (uint64, error) {
+	if a != 0 && b > max/a {
+		return a * b, fmt.Errorf("%w: %d * %d", ErrOverflow, a, b)
+	}
+	return a * b, nil
+}
+
+func divUnsigned(a, b uint64) (uint64, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("%w: %d / %d", ErrDivideByZero, a, b)
+	}
+	return a / b, nil
+}
@@ -0,0 +1,94 @@
+package arith
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDivideFloat64(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		a, b    float64
+		want    float64
+		wantErr error
+	}{
+		{a: 6, b: 3, want: 2},
+		{a: 5, b: 0, want: inf, wantErr: ErrDivideByZero},
+		{a: -5, b: 0, want: -inf, wantErr: ErrDivideByZero},
+		{a: 0, b: 0, want: math.NaN(), wantErr: ErrDivideByZero},
+		{a: inf, b: inf, want: math.NaN(), wantErr: ErrNaN},
+		{a: -inf, b: inf, want: math.NaN(), wantErr: ErrNaN},
+		{a: math.NaN(), b: 1, want: math.NaN(), wantErr: ErrNaN},
+		{a: math.MaxFloat64, b: 0.5, want: inf, wantErr: ErrOverflow},
+		{a: inf, b: 2, want: inf},
+		{a: 1, b: inf, want: 0},
+	}
+	for _, test := range tests {
+		got, err := DivideFloat64(test.a, test.b)
+		if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+			t.Errorf("DivideFloat64(%v, %v) error = %v, want %v", test.a, test.b, err, test.wantErr)
+		}
+		if got != test.want && !(math.IsNaN(got) && math.IsNaN(test.want)) {
+			t.Errorf("DivideFloat64(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	inf := math.Inf(1)
+	if got, err := ReturnError.Divide(inf, inf); !errors.Is(err, ErrNaN) {
+		t.Errorf("ReturnError.Divide(Inf, Inf) = %v, %v, want %v", got, err, ErrNaN)
+	}
+	if got, err := IEEE.Divide(5, 0); err != nil || !math.IsInf(got, 1) {
+		t.Errorf("IEEE.Divide(5, 0) = %v, %v, want +Inf", got, err)
+	}
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrDivideByZero) {
+			t.Errorf("Panic.Divide(1, 0) panicked with %v, want %v", err, ErrDivideByZero)
+		}
+	}()
+	Panic.Divide(1, 0)
+	t.Error("Panic.Divide(1, 0) did not panic")
+}
+
+// result holds the value of any width, for the table of TestCheckedIntegers
+type result struct {
+	value int64
+	err   error
+}
+
+func TestCheckedIntegers(t *testing.T) {
+	tests := []struct {
+		name string
+		got  result
+		want result
+	}{
+		{"AddInt8", int8Result(AddInt8(100, 27)), result{127, nil}},
+		{"AddInt8 overflow", int8Result(AddInt8(100, 100)), result{-56, ErrOverflow}},
+		{"SubInt8 overflow", int8Result(SubInt8(-100, 100)), result{56, ErrOverflow}},
+		{"MulInt16 negative", int16Result(MulInt16(-200, 100)), result{-20000, nil}},
+		{"MulInt32 overflow", int32Result(MulInt32(1<<16, 1<<16)), result{0, ErrOverflow}},
+		{"MulInt64 MinInt64 * -1", int64Result(MulInt64(math.MinInt64, -1)), result{math.MinInt64, ErrOverflow}},
+		{"DivInt64 MinInt64 / -1", int64Result(DivInt64(math.MinInt64, -1)), result{math.MinInt64, ErrOverflow}},
+		{"DivInt64 by zero", int64Result(DivInt64(1, 0)), result{0, ErrDivideByZero}},
+		{"SubUint underflow", uintResult(SubUint(1, 2)), result{-1, ErrOverflow}},
+		{"MulUint64 overflow", uint64Result(MulUint64(1<<32, 1<<32)), result{0, ErrOverflow}},
+		{"AddUint8 overflow", uint8Result(AddUint8(200, 100)), result{44, ErrOverflow}},
+		{"DivUint8 by zero", uint8Result(DivUint8(1, 0)), result{0, ErrDivideByZero}},
+	}
+	for _, test := range tests {
+		if test.got.value != test.want.value || !errors.Is(test.got.err, test.want.err) || (test.want.err == nil && test.got.err != nil) {
+			t.Errorf("%v = %v, %v, want %v, %v", test.name, test.got.value, test.got.err, test.want.value, test.want.err)
+		}
+	}
+}
+
+func int8Result(v int8, err error) result     { return result{int64(v), err} }
+func int16Result(v int16, err error) result   { return result{int64(v), err} }
+func int32Result(v int32, err error) result   { return result{int64(v), err} }
+func int64Result(v int64, err error) result   { return result{v, err} }
+func uintResult(v uint, err error) result     { return result{int64(v), err} }
+func uint8Result(v uint8, err error) result   { return result{int64(v), err} }
+func uint64Result(v uint64, err error) result { return result{int64(v), err} }
@@ -0,0 +1,209 @@
+package arith
+
+import "math"
+
+/*
+Checked integer arithmetic for every width. Each function returns the result Go would give without the check
+(wrapped around on overflow, 0 when dividing by zero) and ErrOverflow or ErrDivideByZero. Use Policy.Handle
+to turn the error into a panic or to ignore it
+*/
+
+func AddInt(a, b int) (int, error) {
+	result, err := addSigned(int64(a), int64(b), minInt, maxInt)
+	return int(result), err
+}
+
+func SubInt(a, b int) (int, error) {
+	result, err := subSigned(int64(a), int64(b), minInt, maxInt)
+	return int(result), err
+}
+
+func MulInt(a, b int) (int, error) {
+	result, err := mulSigned(int64(a), int64(b), minInt, maxInt)
+	return int(result), err
+}
+
+func DivInt(a, b int) (int, error) {
+	result, err := divSigned(int64(a), int64(b), minInt)
+	return int(result), err
+}
+
+func AddInt8(a, b int8) (int8, error) {
+	result, err := addSigned(int64(a), int64(b), math.MinInt8, math.MaxInt8)
+	return int8(result), err
+}
+
+func SubInt8(a, b int8) (int8, error) {
+	result, err := subSigned(int64(a), int64(b), math.MinInt8, math.MaxInt8)
+	return int8(result), err
+}
+
+func MulInt8(a, b int8) (int8, error) {
+	result, err := mulSigned(int64(a), int64(b), math.MinInt8, math.MaxInt8)
+	return int8(result), err
+}
+
+func DivInt8(a, b int8) (int8, error) {
+	result, err := divSigned(int64(a), int64(b), math.MinInt8)
+	return int8(result), err
+}
+
+func AddInt16(a, b int16) (int16, error) {
+	result, err := addSigned(int64(a), int64(b), math.MinInt16, math.MaxInt16)
+	return int16(result), err
+}
+
+func SubInt16(a, b int16) (int16, error) {
+	result, err := subSigned(int64(a), int64(b), math.MinInt16, math.MaxInt16)
+	return int16(result), err
+}
+
+func MulInt16(a, b int16) (int16, error) {
+	result, err := mulSigned(int64(a), int64(b), math.MinInt16, math.MaxInt16)
+	return int16(result), err
+}
+
+func DivInt16(a, b int16) (int16, error) {
+	result, err := divSigned(int64(a), int64(b), math.MinInt16)
+	return int16(result), err
+}
+
+func AddInt32(a, b int32) (int32, error) {
+	result, err := addSigned(int64(a), int64(b), math.MinInt32, math.MaxInt32)
+	return int32(result), err
+}
+
+func SubInt32(a, b int32) (int32, error) {
+	result, err := subSigned(int64(a), int64(b), math.MinInt32, math.MaxInt32)
+	return int32(result), err
+}
+
+func MulInt32(a, b int32) (int32, error) {
+	result, err := mulSigned(int64(a), int64(b), math.MinInt32, math.MaxInt32)
+	return int32(result), err
+}
+
+func DivInt32(a, b int32) (int32, error) {
+	result, err := divSigned(int64(a), int64(b), math.MinInt32)
+	return int32(result), err
+}
+
+func AddInt64(a, b int64) (int64, error) {
+	result, err := addSigned(int64(a), int64(b), math.MinInt64, math.MaxInt64)
+	return int64(result), err
+}
+
+func SubInt64(a, b int64) (int64, error) {
+	result, err := subSigned(int64(a), int64(b), math.MinInt64, math.MaxInt64)
+	return int64(result), err
+}
+
+func MulInt64(a, b int64) (int64, error) {
+	result, err := mulSigned(int64(a), int64(b), math.MinInt64, math.MaxInt64)
+	return int64(result), err
+}
+
+func DivInt64(a, b int64) (int64, error) {
+	result, err := divSigned(int64(a), int64(b), math.MinInt64)
+	return int64(result), err
+}
+
+func AddUint(a, b uint) (uint, error) {
+	result, err := addUnsigned(uint64(a), uint64(b), maxUint)
+	return uint(result), err
+}
+
+func SubUint(a, b uint) (uint, error) {
+	result, err := subUnsigned(uint64(a), uint64(b))
+	return uint(result), err
+}
+
+func MulUint(a, b uint) (uint, error) {
+	result, err := mulUnsigned(uint64(a), uint64(b), maxUint)
+	return uint(result), err
+}
+
+func DivUint(a, b uint) (uint, error) {
+	result, err := divUnsigned(uint64(a), uint64(b))
+	return uint(result), err
+}
+
+func AddUint8(a, b uint8) (uint8, error) {
+	result, err := addUnsigned(uint64(a), uint64(b), math.MaxUint8)
+	return uint8(result), err
+}
+
+func SubUint8(a, b uint8) (uint8, error) {
+	result, err := subUnsigned(uint64(a), uint64(b))
+	return uint8(result), err
+}
+
+func MulUint8(a, b uint8) (uint8, error) {
+	result, err := mulUnsigned(uint64(a), uint64(b), math.MaxUint8)
+	return uint8(result), err
+}
+
+func DivUint8(a, b uint8) (uint8, error) {
+	result, err := divUnsigned(uint64(a), uint64(b))
+	return uint8(result), err
+}
+
+func AddUint16(a, b uint16) (uint16, error) {
+	result, err := addUnsigned(uint64(a), uint64(b), math.MaxUint16)
+	return uint16(result), err
+}
+
+func SubUint16(a, b uint16) (uint16, error) {
+	result, err := subUnsigned(uint64(a), uint64(b))
+	return uint16(result), err
+}
+
+func MulUint16(a, b uint16) (uint16, error) {
+	result, err := mulUnsigned(uint64(a), uint64(b), math.MaxUint16)
+	return uint16(result), err
+}
+
+func DivUint16(a, b uint16) (uint16, error) {
+	result, err := divUnsigned(uint64(a), uint64(b))
+	return uint16(result), err
+}
+
+func AddUint32(a, b uint32) (uint32, error) {
+	result, err := addUnsigned(uint64(a), uint64(b), math.MaxUint32)
+	return uint32(result), err
+}
+
+func SubUint32(a, b uint32) (uint32, error) {
+	result, err := subUnsigned(uint64(a), uint64(b))
+	return uint32(result), err
+}
+
+func MulUint32(a, b uint32) (uint32, error) {
+	result, err := mulUnsigned(uint64(a), uint64(b), math.MaxUint32)
+	return uint32(result), err
+}
+
+func DivUint32(a, b uint32) (uint32, error) {
+	result, err := divUnsigned(uint64(a), uint64(b))
+	return uint32(result), err
+}
+
+func AddUint64(a, b uint64) (uint64, error) {
+	result, err := addUnsigned(uint64(a), uint64(b), math.MaxUint64)
+	return uint64(result), err
+}
+
+func SubUint64(a, b uint64) (uint64, error) {
+	result, err := subUnsigned(uint64(a), uint64(b))
+	return uint64(result), err
+}
+
+func MulUint64(a, b uint64) (uint64, error) {
+	result, err := mulUnsigned(uint64(a), uint64(b), math.MaxUint64)
+	return uint64(result), err
+}
+
+func DivUint64(a, b uint64) (uint64, error) {
+	result, err := divUnsigned(uint64(a), uint64(b))
+	return uint64(result), err
+}
@@ -0,0 +1,106 @@
+package arith
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+Context holds the precision and the rounding mode of the decimal helpers. Precision is the number of digits
+kept after the decimal point by Sum, Divide and the float64 conversions
+*/
+type Context struct {
+	Precision int
+	Rounding  RoundingMode
+}
+
+// DefaultContext keeps 16 digits after the decimal point and rounds half to even
+var DefaultContext = Context{Precision: 16, Rounding: HalfEven}
+
+// Money keeps cents and rounds half up, the way prices are rounded
+var Money = Context{Precision: 2, Rounding: HalfUp}
+
+func (c Context) Round(d Decimal) Decimal {
+	return d.Round(c.Precision, c.Rounding)
+}
+
+// Sum adds the values exactly and rounds only the total
+func (c Context) Sum(values ...Decimal) Decimal {
+	total := Decimal{}
+	for _, v := range values {
+		total = total.Add(v)
+	}
+	return c.Round(total)
+}
+
+func (c Context) Divide(a, b Decimal) (Decimal, error) {
+	return a.Quo(b, c.Precision, c.Rounding)
+}
+
+/*
+SumFloat64 has the signature of a float sum but adds in decimal, so 0.1 + 0.2 is 0.3
+and not 0.30000000000000004
+*/
+func (c Context) SumFloat64(values ...float64) (float64, error) {
+	total := Decimal{}
+	for _, v := range values {
+		d, err := DecimalFromFloat64(v)
+		if err != nil {
+			return 0, err
+		}
+		total = total.Add(d)
+	}
+	return c.Round(total).Float64()
+}
+
+// DivideFloat64 is divideWithTwoReturnTypes done in decimal with the precision of the context
+func (c Context) DivideFloat64(a, b float64) (float64, error) {
+	x, err := DecimalFromFloat64(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := DecimalFromFloat64(b)
+	if err != nil {
+		return 0, err
+	}
+	result, err := c.Divide(x, y)
+	if err != nil {
+		return 0, err
+	}
+	return result.Float64()
+}
+
+// DivideInts divides two ints without losing the fraction
+func (c Context) DivideInts(a, b int) (Decimal, error) {
+	return c.Divide(DecimalFromInt(a), DecimalFromInt(b))
+}
+
+// DivideBig divides two big integers without losing the fraction
+func (c Context) DivideBig(a, b *big.Int) (Decimal, error) {
+	return c.Divide(DecimalFromBigInt(a), DecimalFromBigInt(b))
+}
+
+// SumBig adds ints into a big.Int, it never overflows
+func SumBig(values ...int) *big.Int {
+	total, v := new(big.Int), new(big.Int)
+	for _, value := range values {
+		total.Add(total, v.SetInt64(int64(value)))
+	}
+	return total
+}
+
+/*
+SumInts has the signature of getSum with an error: the total is exact and ErrOverflow is returned only when
+the total does not fit in an int, intermediate overflows like MaxInt + 1 - 1 are fine
+*/
+func SumInts(values ...int) (int, error) {
+	return BigToInt(SumBig(values...))
+}
+
+// BigToInt converts back to int, ErrOverflow when x does not fit
+func BigToInt(x *big.Int) (int, error) {
+	if !x.IsInt64() || x.Int64() > maxInt || x.Int64() < minInt {
+		return 0, fmt.Errorf("%w: %v does not fit in int", ErrOverflow, x)
+	}
+	return int(x.Int64()), nil
+}
@@ -0,0 +1,280 @@
+package arith
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode decides what happens to the digits a Decimal loses when it is rounded or divided
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest, ties to the even digit (banker's rounding)
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest, ties away from zero
+	HalfUp
+	// HalfDown rounds to the nearest, ties towards zero
+	HalfDown
+	// Down truncates towards zero
+	Down
+	// Up rounds away from zero
+	Up
+	// Floor rounds towards negative infinity
+	Floor
+	// Ceiling rounds towards positive infinity
+	Ceiling
+)
+
+var roundingModeNames = [...]string{"HalfEven", "HalfUp", "HalfDown", "Down", "Up", "Floor", "Ceiling"}
+
+func (m RoundingMode) String() string {
+	if m < 0 || int(m) >= len(roundingModeNames) {
+		return "RoundingMode(" + strconv.Itoa(int(m)) + ")"
+	}
+	return roundingModeNames[m]
+}
+
+/*
+Decimal is an exact decimal number: coef * 10^-scale. Add, Sub and Mul never lose digits,
+only Round and Quo do, with the rounding mode they are given. The zero value is 0.
+A Decimal is immutable, every operation returns a new one
+*/
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+var bigTen = big.NewInt(10)
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) coefficient() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// NewDecimal returns unscaled * 10^-scale, NewDecimal(1050, 2) is 10.50
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return normalize(big.NewInt(unscaled), scale)
+}
+
+func DecimalFromInt(v int) Decimal {
+	return Decimal{coef: big.NewInt(int64(v))}
+}
+
+func DecimalFromBigInt(v *big.Int) Decimal {
+	return Decimal{coef: new(big.Int).Set(v)}
+}
+
+/*
+DecimalFromFloat64 converts with the shortest decimal that reads back as the same float,
+so 0.1 becomes 0.1 and not 0.1000000000000000055511151231257827
+*/
+func DecimalFromFloat64(f float64) (Decimal, error) {
+	if math.IsNaN(f) {
+		return Decimal{}, ErrNaN
+	}
+	if math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrOverflow, f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// MaxExponent bounds the exponent of ParseDecimal, 1e300000000 would need 300 million digits
+const MaxExponent = 10000
+
+// ParseDecimal reads numbers like "-12.50", "+3" or "1.5e3". The exponent must be within ±MaxExponent
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("arith: invalid decimal %q", s)
+		}
+		if e > MaxExponent || e < -MaxExponent {
+			return Decimal{}, fmt.Errorf("%w: exponent of %q is beyond ±%d", ErrOverflow, s, MaxExponent)
+		}
+		mantissa, exponent = s[:i], e
+	}
+	digits, scale := mantissa, 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		scale = len(mantissa) - i - 1
+	}
+	unsigned := strings.TrimLeft(digits, "+-")
+	if len(digits)-len(unsigned) > 1 || unsigned == "" || strings.Trim(unsigned, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("arith: invalid decimal %q", s)
+	}
+	coef, _ := new(big.Int).SetString(digits, 10)
+	return normalize(coef, scale-exponent), nil
+}
+
+// normalize keeps the scale non negative, 5e3 is stored as 5000 with scale 0
+func normalize(coef *big.Int, scale int) Decimal {
+	if scale < 0 {
+		return Decimal{coef: coef.Mul(coef, pow10(-scale))}
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// Scale is the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.coefficient().Sign()
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.coefficient()), scale: d.scale}
+}
+
+// align returns the coefficients of d and e at the same (the larger) scale
+func align(d, e Decimal) (*big.Int, *big.Int, int) {
+	a, b := new(big.Int).Set(d.coefficient()), new(big.Int).Set(e.coefficient())
+	switch {
+	case d.scale < e.scale:
+		a.Mul(a, pow10(e.scale-d.scale))
+		return a, b, e.scale
+	case d.scale > e.scale:
+		b.Mul(b, pow10(d.scale-e.scale))
+	}
+	return a, b, d.scale
+}
+
+// Cmp returns -1, 0 or +1 like big.Int.Cmp. 1.50 and 1.5 are equal
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.coefficient(), e.coefficient()), scale: d.scale + e.scale}
+}
+
+// Round keeps at most scale digits after the decimal point. A Decimal with fewer digits is returned as it is
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	if d.scale <= scale {
+		return d
+	}
+	return Decimal{coef: roundQuo(d.coefficient(), pow10(d.scale-scale), mode), scale: scale}
+}
+
+// Quo divides d by e and rounds the result to scale digits after the decimal point
+func (d Decimal) Quo(e Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if e.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("%w: %v / %v", ErrDivideByZero, d, e)
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	// d/e = (coef(d) / coef(e)) * 10^(e.scale-d.scale), shifted so the quotient has the wanted scale
+	num, den := new(big.Int).Set(d.coefficient()), new(big.Int).Set(e.coefficient())
+	if shift := scale + e.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return Decimal{coef: roundQuo(num, den, mode), scale: scale}, nil
+}
+
+// roundQuo returns num/den rounded to an integer with the mode
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	sign := num.Sign() * den.Sign()
+	// twice the remainder against the divisor tells below, at or above half
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(den))
+
+	away := false
+	switch mode {
+	case Up:
+		away = true
+	case Floor:
+		away = sign < 0
+	case Ceiling:
+		away = sign > 0
+	case HalfUp:
+		away = half >= 0
+	case HalfDown:
+		away = half > 0
+	case HalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+func (d Decimal) String() string {
+	coef := d.coefficient()
+	digits := new(big.Int).Abs(coef).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if coef.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 returns the nearest float64, ErrOverflow when the decimal is out of its range
+func (d Decimal) Float64() (float64, error) {
+	f, err := strconv.ParseFloat(d.String(), 64)
+	if err != nil {
+		return f, fmt.Errorf("%w: %v", ErrOverflow, d)
+	}
+	return f, nil
+}
+
+// BigInt rounds to an integer with the mode
+func (d Decimal) BigInt(mode RoundingMode) *big.Int {
+	return new(big.Int).Set(d.Round(0, mode).coefficient())
+}
+
+// Int rounds to an int with the mode, ErrOverflow when it does not fit
+func (d Decimal) Int(mode RoundingMode) (int, error) {
+	return BigToInt(d.BigInt(mode))
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
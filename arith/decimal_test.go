@@ -0,0 +1,109 @@
+package arith
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{input: "12.50", want: "12.50"},
+		{input: "-0.005", want: "-0.005"},
+		{input: "+3", want: "3"},
+		{input: ".5", want: "0.5"},
+		{input: "12.", want: "12"},
+		{input: "1.5e3", want: "1500"},
+		{input: "15E-3", want: "0.015"},
+		{input: "1e10000", want: "1" + strings.Repeat("0", 10000)},
+		{input: "1e10001", wantErr: ErrOverflow},
+		{input: "1e300000000", wantErr: ErrOverflow},
+		{input: "1e-9223372036854775808", wantErr: ErrOverflow},
+		{input: "1e99999999999999999999", wantErr: errSyntax},
+		{input: "x", wantErr: errSyntax},
+		{input: "--1", wantErr: errSyntax},
+		{input: "", wantErr: errSyntax},
+		{input: "1.2.3", wantErr: errSyntax},
+	}
+	for _, test := range tests {
+		name := test.input
+		if len(name) > 20 {
+			name = name[:20]
+		}
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseDecimal(test.input)
+			switch {
+			case test.wantErr == errSyntax:
+				if err == nil {
+					t.Errorf("ParseDecimal(%q) = %v, want an error", test.input, d)
+				}
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("ParseDecimal(%q) error = %v, want %v", test.input, err, test.wantErr)
+				}
+			case err != nil:
+				t.Errorf("ParseDecimal(%q) error = %v", test.input, err)
+			case d.String() != test.want:
+				t.Errorf("ParseDecimal(%q) = %v, want %v", test.input, d, test.want)
+			}
+		})
+	}
+}
+
+// errSyntax marks the cases where any error will do
+var errSyntax = errors.New("syntax")
+
+func TestRound(t *testing.T) {
+	modes := []RoundingMode{HalfEven, HalfUp, HalfDown, Down, Up, Floor, Ceiling}
+	tests := []struct {
+		input string
+		want  [7]string // in the order of modes
+	}{
+		{"2.5", [7]string{"2", "3", "2", "2", "3", "2", "3"}},
+		{"-2.5", [7]string{"-2", "-3", "-2", "-2", "-3", "-3", "-2"}},
+		{"1.5", [7]string{"2", "2", "1", "1", "2", "1", "2"}},
+		{"1.51", [7]string{"2", "2", "2", "1", "2", "1", "2"}},
+		{"-1.2", [7]string{"-1", "-1", "-1", "-1", "-2", "-2", "-1"}},
+		{"7", [7]string{"7", "7", "7", "7", "7", "7", "7"}},
+	}
+	for _, test := range tests {
+		d, err := ParseDecimal(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, mode := range modes {
+			if got := d.Round(0, mode).String(); got != test.want[i] {
+				t.Errorf("%v.Round(0, %v) = %v, want %v", test.input, mode, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got, err := DefaultContext.SumFloat64(0.1, 0.2); err != nil || got != 0.3 {
+		t.Errorf("SumFloat64(0.1, 0.2) = %v, %v, want 0.3", got, err)
+	}
+	if got, err := Money.DivideFloat64(-10, 3); err != nil || got != -3.33 {
+		t.Errorf("Money.DivideFloat64(-10, 3) = %v, %v, want -3.33", got, err)
+	}
+	if _, err := Money.DivideInts(1, 0); !errors.Is(err, ErrDivideByZero) {
+		t.Errorf("DivideInts(1, 0) error = %v, want %v", err, ErrDivideByZero)
+	}
+	if got, _ := (Context{Precision: 30, Rounding: Down}).DivideInts(1, 7); got.String() != "0.142857142857142857142857142857" {
+		t.Errorf("1 / 7 = %v", got)
+	}
+	if got, err := SumInts(math.MaxInt64, 1, -1); err != nil || got != math.MaxInt64 {
+		t.Errorf("SumInts(MaxInt64, 1, -1) = %v, %v", got, err)
+	}
+	if _, err := SumInts(math.MaxInt64, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("SumInts(MaxInt64, 1) error = %v, want %v", err, ErrOverflow)
+	}
+	if _, err := DecimalFromFloat64(math.NaN()); !errors.Is(err, ErrNaN) {
+		t.Errorf("DecimalFromFloat64(NaN) error = %v, want %v", err, ErrNaN)
+	}
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Continent is what the canSeeX roles give access to
+type Continent int
+
+const (
+	Africa Continent = iota
+	Asia
+	Europe
+	NorthAmerica
+	SouthAmerica
+	Oceania
+	Antarctica
+)
+
+var continents = []struct {
+	name       string
+	permission Permission
+}{
+	{"Africa", SeeAfrica},
+	{"Asia", SeeAsia},
+	{"Europe", SeeEurope},
+	{"North America", SeeNorthAmerica},
+	{"South America", SeeSouthAmerica},
+	{"Oceania", SeeOceania},
+	{"Antarctica", SeeAntarctica},
+}
+
+func (c Continent) String() string {
+	if c < 0 || int(c) >= len(continents) {
+		return fmt.Sprintf("Continent(%d)", int(c))
+	}
+	return continents[c].name
+}
+
+func (c Continent) permission() (Permission, bool) {
+	if c < 0 || int(c) >= len(continents) {
+		return 0, false
+	}
+	return continents[c].permission, true
+}
+
+// ParseContinent accepts the names printed by String, ignoring case and spaces ("northamerica" works too)
+func ParseContinent(name string) (Continent, error) {
+	wanted := strings.ReplaceAll(name, " ", "")
+	for i, c := range continents {
+		if strings.EqualFold(strings.ReplaceAll(c.name, " ", ""), wanted) {
+			return Continent(i), nil
+		}
+	}
+	return 0, fmt.Errorf("auth: unknown continent %q", name)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+Permission is the position of a bit in a RoleSet. Positions are written down explicitly and never reused:
+they are what gets stored, so adding a permission must not move an existing one.
+The first seven positions are the bits of the 8 bit Roles type, which keeps old masks readable
+*/
+type Permission uint
+
+/*
+MaxPermissions bounds the positions to 0..MaxPermissions-1. A RoleSet grows to the word of its highest bit,
+so without a bound "bit900000000000" in a request body would allocate gigabytes
+*/
+const MaxPermissions = 1024
+
+const (
+	Admin           Permission = 0
+	Headquarters    Permission = 1
+	SeeAfrica       Permission = 2
+	SeeAsia         Permission = 3
+	SeeEurope       Permission = 4
+	SeeNorthAmerica Permission = 5
+	SeeSouthAmerica Permission = 6
+	SeeOceania      Permission = 7
+	SeeAntarctica   Permission = 8
+)
+
+var permissions = struct {
+	sync.RWMutex
+	names map[Permission]string
+}{names: map[Permission]string{
+	Admin:           "admin",
+	Headquarters:    "headquarters",
+	SeeAfrica:       "canSeeAfrica",
+	SeeAsia:         "canSeeAsia",
+	SeeEurope:       "canSeeEurope",
+	SeeNorthAmerica: "canSeeNorthAmerica",
+	SeeSouthAmerica: "canSeeSouthAmerica",
+	SeeOceania:      "canSeeOceania",
+	SeeAntarctica:   "canSeeAntarctica",
+}}
+
+/*
+NewPermission registers a named permission at a fixed bit position, for permissions that only some services
+know about. It panics if the position or the name is already taken, the same way flag redefinition does
+*/
+func NewPermission(bit uint, name string) Permission {
+	if bit >= MaxPermissions {
+		panic(fmt.Sprintf("auth: permission bit %d is out of range, the limit is %d", bit, MaxPermissions))
+	}
+	permissions.Lock()
+	defer permissions.Unlock()
+	if existing, ok := permissions.names[Permission(bit)]; ok {
+		panic(fmt.Sprintf("auth: permission bit %d already used by %q", bit, existing))
+	}
+	for _, existing := range permissions.names {
+		if strings.EqualFold(existing, name) {
+			panic(fmt.Sprintf("auth: permission %q already registered", name))
+		}
+	}
+	permissions.names[Permission(bit)] = name
+	return Permission(bit)
+}
+
+// String returns the registered name, or "bitN" for a position without one
+func (p Permission) String() string {
+	permissions.RLock()
+	defer permissions.RUnlock()
+	if name, ok := permissions.names[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("bit%d", uint(p))
+}
+
+// ParsePermission accepts a registered name (case insensitive) or "bitN" with N below MaxPermissions
+func ParsePermission(name string) (Permission, error) {
+	permissions.RLock()
+	defer permissions.RUnlock()
+	for p, n := range permissions.names {
+		if strings.EqualFold(n, name) {
+			return p, nil
+		}
+	}
+	var bit uint
+	if _, err := fmt.Sscanf(name, "bit%d", &bit); err == nil && fmt.Sprintf("bit%d", bit) == name {
+		if bit >= MaxPermissions {
+			return 0, fmt.Errorf("auth: permission %q is out of range, the limit is bit%d", name, MaxPermissions-1)
+		}
+		return Permission(bit), nil
+	}
+	return 0, fmt.Errorf("auth: unknown permission %q", name)
+}
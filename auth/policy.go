@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"firstApp/logging"
+)
+
+type Effect int
+
+const (
+	Allow Effect = iota
+	Deny
+)
+
+func (e Effect) String() string {
+	if e == Deny {
+		return "deny"
+	}
+	return "allow"
+}
+
+/*
+Rule applies to a request when the action and the resource match and the subject holds the roles of the rule.
+Action and Resource match exactly, "*" matches anything and a trailing "*" matches a prefix ("population:*")
+*/
+type Rule struct {
+	Name     string
+	Action   string
+	Resource string
+	Effect   Effect
+	// Roles must all be held by the subject. An empty set means no requirement
+	Roles RoleSet
+	// AnyOf must share at least one permission with the subject. An empty set means no requirement
+	AnyOf RoleSet
+}
+
+func (rule Rule) applies(request Request) bool {
+	return matches(rule.Action, request.Action) &&
+		matches(rule.Resource, request.Resource) &&
+		request.Roles.HasAll(rule.Roles) &&
+		(rule.AnyOf.IsEmpty() || request.Roles.HasAny(rule.AnyOf))
+}
+
+func matches(pattern, value string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
+
+// Request is the question asked to the policy: can Subject, holding Roles, do Action on Resource
+type Request struct {
+	Subject  string
+	Roles    RoleSet
+	Action   string
+	Resource string
+}
+
+// Decision is the answer, together with the rules that led to it
+type Decision struct {
+	Request Request
+	Allowed bool
+	// Rule is the name of the deciding rule, empty when nothing matched
+	Rule string
+	// Matched lists every rule that applied, in the order they were added
+	Matched []string
+	Reason  string
+}
+
+/*
+Policy evaluates requests with deny-overrides semantics: one applying Deny rule wins over any number of Allow
+rules, and a request no rule allows is denied. Every decision is written to the audit logger, if there is one
+*/
+type Policy struct {
+	mutex  sync.RWMutex
+	rules  []Rule
+	logger *logging.Logger
+}
+
+// NewPolicy creates a policy. logger may be nil to skip the audit trail
+func NewPolicy(logger *logging.Logger, rules ...Rule) *Policy {
+	return &Policy{rules: append([]Rule(nil), rules...), logger: logger}
+}
+
+func (p *Policy) Add(rules ...Rule) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.rules = append(p.rules, rules...)
+}
+
+func (p *Policy) Allowed(request Request) bool {
+	return p.Evaluate(request).Allowed
+}
+
+func (p *Policy) Evaluate(request Request) Decision {
+	p.mutex.RLock()
+	decision := Decision{Request: request}
+	var firstAllow, firstDeny *Rule
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.applies(request) {
+			continue
+		}
+		decision.Matched = append(decision.Matched, rule.Name)
+		if rule.Effect == Deny && firstDeny == nil {
+			firstDeny = rule
+		} else if rule.Effect == Allow && firstAllow == nil {
+			firstAllow = rule
+		}
+	}
+	p.mutex.RUnlock()
+
+	switch {
+	case firstDeny != nil:
+		decision.Rule = firstDeny.Name
+		decision.Reason = fmt.Sprintf("denied by rule %q", firstDeny.Name)
+		if firstAllow != nil {
+			decision.Reason += fmt.Sprintf(", overriding allow rule %q", firstAllow.Name)
+		}
+	case firstAllow != nil:
+		decision.Allowed = true
+		decision.Rule = firstAllow.Name
+		decision.Reason = fmt.Sprintf("allowed by rule %q", firstAllow.Name)
+	default:
+		decision.Reason = fmt.Sprintf("no rule allows %v on %v for roles %q", request.Action, request.Resource, request.Roles)
+	}
+	p.audit(decision)
+	return decision
+}
+
+func (p *Policy) audit(decision Decision) {
+	if p.logger == nil {
+		return
+	}
+	severity := logging.Info
+	if !decision.Allowed {
+		severity = logging.Warning
+	}
+	p.logger.Log(severity, "authorization decision",
+		logging.F("subject", decision.Request.Subject),
+		logging.F("roles", decision.Request.Roles.String()),
+		logging.F("action", decision.Request.Action),
+		logging.F("resource", decision.Request.Resource),
+		logging.F("allowed", decision.Allowed),
+		logging.F("rule", decision.Rule),
+		logging.F("reason", decision.Reason),
+	)
+}
+
+// PopulationResource names the population data of a continent, e.g. "population:Europe"
+func PopulationResource(continent Continent) string {
+	return "population:" + continent.String()
+}
+
+/*
+RegionRules allows action on the population data of every continent to the holders of its canSeeX role.
+For example RegionRules("view") lets canSeeEurope view "population:Europe"
+*/
+func RegionRules(action string) []Rule {
+	rules := make([]Rule, 0, len(continents))
+	for i, c := range continents {
+		rules = append(rules, Rule{
+			Name:     fmt.Sprintf("%v %v", action, PopulationResource(Continent(i))),
+			Action:   action,
+			Resource: PopulationResource(Continent(i)),
+			Effect:   Allow,
+			Roles:    NewRoleSet(c.permission),
+		})
+	}
+	return rules
+}
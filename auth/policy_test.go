@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"firstApp/logging"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := NewPolicy(nil,
+		Rule{Name: "read all", Action: "view", Resource: "population:*", Effect: Allow},
+		Rule{Name: "admin writes", Action: "*", Resource: "*", Effect: Allow, Roles: NewRoleSet(Admin)},
+		Rule{Name: "no antarctica", Action: "view", Resource: "population:Antarctica", Effect: Deny,
+			AnyOf: NewRoleSet(SeeEurope, SeeAsia)},
+	)
+	tests := []struct {
+		name    string
+		request Request
+		allowed bool
+		rule    string
+		matched []string
+		reason  string
+	}{
+		{"prefix match", Request{Action: "view", Resource: "population:Europe"},
+			true, "read all", []string{"read all"}, `allowed by rule "read all"`},
+		{"wildcard with required roles", Request{Roles: NewRoleSet(Admin), Action: "delete", Resource: "doctors"},
+			true, "admin writes", []string{"admin writes"}, `allowed by rule "admin writes"`},
+		{"deny overrides allow", Request{Roles: NewRoleSet(SeeAsia), Action: "view", Resource: "population:Antarctica"},
+			false, "no antarctica", []string{"read all", "no antarctica"},
+			`denied by rule "no antarctica", overriding allow rule "read all"`},
+		{"deny needs one of AnyOf", Request{Roles: NewRoleSet(SeeAfrica), Action: "view", Resource: "population:Antarctica"},
+			true, "read all", []string{"read all"}, `allowed by rule "read all"`},
+		{"default deny", Request{Action: "delete", Resource: "doctors"},
+			false, "", nil, "no rule allows delete on doctors"},
+		{"prefix is not a substring", Request{Action: "view", Resource: "populations"},
+			false, "", nil, "no rule allows view on populations"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decision := policy.Evaluate(test.request)
+			if decision.Allowed != test.allowed || decision.Rule != test.rule {
+				t.Errorf("Evaluate = allowed %v by %q, want %v by %q", decision.Allowed, decision.Rule, test.allowed, test.rule)
+			}
+			if !reflect.DeepEqual(decision.Matched, test.matched) {
+				t.Errorf("Matched = %q, want %q", decision.Matched, test.matched)
+			}
+			if !strings.HasPrefix(decision.Reason, test.reason) {
+				t.Errorf("Reason = %q, want prefix %q", decision.Reason, test.reason)
+			}
+			if policy.Allowed(test.request) != test.allowed {
+				t.Errorf("Allowed disagrees with Evaluate")
+			}
+		})
+	}
+}
+
+func TestRegionRules(t *testing.T) {
+	policy := NewPolicy(nil, RegionRules("view")...)
+	tests := []struct {
+		name     string
+		roles    RoleSet
+		action   string
+		resource string
+		allowed  bool
+	}{
+		{"own continent", NewRoleSet(SeeEurope), "view", "population:Europe", true},
+		{"other continent", NewRoleSet(SeeEurope), "view", "population:Asia", false},
+		{"several continents", NewRoleSet(SeeEurope, SeeAsia), "view", "population:Asia", true},
+		{"other action", NewRoleSet(SeeEurope), "edit", "population:Europe", false},
+		{"admin is not a region role", NewRoleSet(Admin), "view", "population:Europe", false},
+		{"no roles", NewRoleSet(), "view", "population:Europe", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := Request{Roles: test.roles, Action: test.action, Resource: test.resource}
+			if got := policy.Allowed(request); got != test.allowed {
+				t.Errorf("Allowed = %v, want %v", got, test.allowed)
+			}
+		})
+	}
+
+	decision := policy.Evaluate(Request{Roles: NewRoleSet(SeeEurope), Action: "view", Resource: PopulationResource(Europe)})
+	if decision.Rule != "view population:Europe" {
+		t.Errorf("Rule = %q, want %q", decision.Rule, "view population:Europe")
+	}
+}
+
+func TestPolicyAudit(t *testing.T) {
+	ring := logging.NewRingSink(10)
+	logger := logging.New(logging.Config{Sinks: []logging.Sink{ring}})
+	policy := NewPolicy(logger,
+		Rule{Name: "viewers", Action: "view", Resource: "*", Effect: Allow},
+		Rule{Name: "no secrets", Action: "*", Resource: "secrets", Effect: Deny},
+	)
+	policy.Allowed(Request{Subject: "ann", Roles: NewRoleSet(SeeEurope), Action: "view", Resource: "population:Europe"})
+	policy.Allowed(Request{Subject: "bob", Action: "view", Resource: "secrets"})
+	if _, err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := ring.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	tests := []struct {
+		severity logging.Severity
+		fields   map[string]interface{}
+	}{
+		{logging.Info, map[string]interface{}{
+			"subject": "ann", "roles": "canSeeEurope", "allowed": true, "rule": "viewers",
+			"reason": `allowed by rule "viewers"`,
+		}},
+		{logging.Warning, map[string]interface{}{
+			"subject": "bob", "allowed": false, "rule": "no secrets",
+			"reason": `denied by rule "no secrets", overriding allow rule "viewers"`,
+		}},
+	}
+	for i, test := range tests {
+		entry := entries[i]
+		if entry.Severity != test.severity || entry.Message != "authorization decision" {
+			t.Errorf("entry %d = %v %q, want %v", i, entry.Severity, entry.Message, test.severity)
+		}
+		for key, want := range test.fields {
+			if got := field(entry, key); got != want {
+				t.Errorf("entry %d: %v = %v, want %v", i, key, got, want)
+			}
+		}
+	}
+}
+
+func field(entry logging.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
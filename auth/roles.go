@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Roles is a bitmask of permissions. Every flag is a single bit built with 1 << iota, so a set of roles
+is checked with a single AND. A byte only holds 8 flags: use RoleSet for anything beyond the original ones.
+Bit i of Roles is Permission(i), so Set converts without changing the meaning of stored values
+*/
+type Roles byte
+
+const (
+	IsAdmin Roles = 1 << iota
+	IsHeadquarters
+	CanSeeAfrica
+	CanSeeAsia
+	CanSeeEurope
+	CanSeeNorthAmerica
+	CanSeeSouthAmerica
+)
+
+// roleNames is in bit order. These names are used by String, ParseRoles and JSON
+var roleNames = []struct {
+	role Roles
+	name string
+}{
+	{IsAdmin, "admin"},
+	{IsHeadquarters, "headquarters"},
+	{CanSeeAfrica, "canSeeAfrica"},
+	{CanSeeAsia, "canSeeAsia"},
+	{CanSeeEurope, "canSeeEurope"},
+	{CanSeeNorthAmerica, "canSeeNorthAmerica"},
+	{CanSeeSouthAmerica, "canSeeSouthAmerica"},
+}
+
+func (r *Roles) Grant(roles Roles) {
+	*r |= roles
+}
+
+func (r *Roles) Revoke(roles Roles) {
+	*r &^= roles
+}
+
+// Has is true when r contains the single role given. For a combination of roles it behaves as HasAll
+func (r Roles) Has(role Roles) bool {
+	return r.HasAll(role)
+}
+
+func (r Roles) HasAll(roles Roles) bool {
+	return r&roles == roles
+}
+
+func (r Roles) HasAny(roles Roles) bool {
+	return r&roles != 0
+}
+
+// CanSee reports whether the canSeeX flag of the continent is set. Being admin does not imply it
+func (r Roles) CanSee(continent Continent) bool {
+	return r.Set().CanSee(continent)
+}
+
+// Set converts the byte mask into a RoleSet holding the same permissions
+func (r Roles) Set() RoleSet {
+	return RoleSetFromBits(uint64(r))
+}
+
+/*
+String formats the roles as names separated by "|", e.g. "admin|canSeeEurope". Bits without a name are
+written in hex so the value survives a round trip through ParseRoles
+*/
+func (r Roles) String() string {
+	var names []string
+	rest := r
+	for _, rn := range roleNames {
+		if r&rn.role != 0 {
+			names = append(names, rn.name)
+			rest &^= rn.role
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("%#x", byte(rest)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseRoles is the inverse of String. Names are case insensitive and the empty string means no roles
+func ParseRoles(text string) (Roles, error) {
+	var result Roles
+	for _, part := range strings.Split(text, "|") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		role, err := parseRole(part)
+		if err != nil {
+			return 0, err
+		}
+		result |= role
+	}
+	return result, nil
+}
+
+func parseRole(name string) (Roles, error) {
+	for _, rn := range roleNames {
+		if strings.EqualFold(rn.name, name) {
+			return rn.role, nil
+		}
+	}
+	if value, err := strconv.ParseUint(name, 0, 8); err == nil {
+		return Roles(value), nil
+	}
+	return 0, fmt.Errorf("auth: unknown role %q", name)
+}
+
+func (r Roles) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Roles) UnmarshalText(text []byte) error {
+	roles, err := ParseRoles(string(text))
+	if err != nil {
+		return err
+	}
+	*r = roles
+	return nil
+}
+
+// UnmarshalJSON accepts the string form written by MarshalText and also a raw number
+func (r *Roles) UnmarshalJSON(data []byte) error {
+	var number uint8
+	if err := json.Unmarshal(data, &number); err == nil {
+		*r = Roles(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("auth: roles must be a string or a number: %v", err)
+	}
+	return r.UnmarshalText([]byte(text))
+}
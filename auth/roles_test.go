@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRolesMembership(t *testing.T) {
+	roles := IsAdmin | CanSeeEurope
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"Has a granted role", roles.Has(IsAdmin), true},
+		{"Has a missing role", roles.Has(CanSeeAsia), false},
+		{"Has of a combination is HasAll", roles.Has(IsAdmin | CanSeeAsia), false},
+		{"HasAll of the granted roles", roles.HasAll(IsAdmin | CanSeeEurope), true},
+		{"HasAll with one missing", roles.HasAll(IsAdmin | IsHeadquarters), false},
+		{"HasAny with one granted", roles.HasAny(CanSeeAsia | CanSeeEurope), true},
+		{"HasAny with none granted", roles.HasAny(CanSeeAsia | CanSeeAfrica), false},
+		{"HasAll of nothing", Roles(0).HasAll(0), true},
+		{"HasAny of nothing", roles.HasAny(0), false},
+		{"CanSee a granted continent", roles.CanSee(Europe), true},
+		{"admin does not imply CanSee", roles.CanSee(Asia), false},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%v = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestRolesGrantRevoke(t *testing.T) {
+	var roles Roles
+	roles.Grant(IsAdmin | CanSeeAsia)
+	roles.Grant(IsAdmin)
+	if roles != IsAdmin|CanSeeAsia {
+		t.Errorf("after Grant roles = %v", roles)
+	}
+	roles.Revoke(IsAdmin | IsHeadquarters)
+	if roles != CanSeeAsia {
+		t.Errorf("after Revoke roles = %v, want canSeeAsia", roles)
+	}
+	roles.Revoke(CanSeeAsia)
+	if roles != 0 {
+		t.Errorf("after revoking everything roles = %v", roles)
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Roles
+		wantErr bool
+	}{
+		{text: "", want: 0},
+		{text: "admin", want: IsAdmin},
+		{text: "admin|canSeeEurope", want: IsAdmin | CanSeeEurope},
+		{text: " HEADQUARTERS | canseesouthamerica ", want: IsHeadquarters | CanSeeSouthAmerica},
+		{text: "admin|0x80", want: IsAdmin | 0x80},
+		{text: "admin||", want: IsAdmin},
+		{text: "nobody", wantErr: true},
+		{text: "admin|0x100", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseRoles(test.text)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseRoles(%q) = %v, want an error", test.text, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ParseRoles(%q) = %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestRolesString(t *testing.T) {
+	tests := []struct {
+		roles Roles
+		want  string
+	}{
+		{0, ""},
+		{IsAdmin, "admin"},
+		{CanSeeEurope | IsAdmin, "admin|canSeeEurope"},
+		{IsHeadquarters | 0x80, "headquarters|0x80"},
+	}
+	for _, test := range tests {
+		if got := test.roles.String(); got != test.want {
+			t.Errorf("Roles(%#x).String() = %q, want %q", byte(test.roles), got, test.want)
+		}
+		if back, err := ParseRoles(test.roles.String()); err != nil || back != test.roles {
+			t.Errorf("ParseRoles(%q) = %v, %v, want the original %#x", test.roles.String(), back, err, byte(test.roles))
+		}
+	}
+}
+
+func TestRolesJSON(t *testing.T) {
+	type user struct {
+		Roles Roles `json:"roles"`
+	}
+	data, err := json.Marshal(user{Roles: IsAdmin | CanSeeAfrica})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"roles":"admin|canSeeAfrica"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	tests := []struct {
+		json    string
+		want    Roles
+		wantErr bool
+	}{
+		{json: string(data), want: IsAdmin | CanSeeAfrica},
+		{json: `{"roles":""}`, want: 0},
+		{json: `{"roles":17}`, want: IsAdmin | CanSeeEurope},
+		{json: `{"roles":"canSeeAsia|0x80"}`, want: CanSeeAsia | 0x80},
+		{json: `{"roles":"superuser"}`, wantErr: true},
+		{json: `{"roles":256}`, wantErr: true},
+		{json: `{"roles":-1}`, wantErr: true},
+		{json: `{"roles":true}`, wantErr: true},
+	}
+	for _, test := range tests {
+		var u user
+		err := json.Unmarshal([]byte(test.json), &u)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %v, want an error", test.json, u.Roles)
+			}
+			continue
+		}
+		if err != nil || u.Roles != test.want {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v", test.json, u.Roles, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+/*
+RoleSet is a bitmask of any length. Membership is still a shift and an AND, on the word holding the bit.
+The zero value is an empty set. Grant and Revoke never modify a backing array another copy may share,
+so RoleSet values can be passed around like the old Roles byte
+*/
+type RoleSet struct {
+	words []uint64
+}
+
+func NewRoleSet(permissions ...Permission) RoleSet {
+	var rs RoleSet
+	rs.Grant(permissions...)
+	return rs
+}
+
+// RoleSetFromBits converts a stored 64 bit mask. Bit i is Permission(i)
+func RoleSetFromBits(mask uint64) RoleSet {
+	if mask == 0 {
+		return RoleSet{}
+	}
+	return RoleSet{words: []uint64{mask}}
+}
+
+func (rs RoleSet) Has(permission Permission) bool {
+	word := int(permission / 64)
+	return word < len(rs.words) && rs.words[word]&(1<<(permission%64)) != 0
+}
+
+func (rs RoleSet) HasAll(other RoleSet) bool {
+	for i, w := range other.words {
+		if rs.word(i)&w != w {
+			return false
+		}
+	}
+	return true
+}
+
+func (rs RoleSet) HasAny(other RoleSet) bool {
+	for i, w := range other.words {
+		if rs.word(i)&w != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs RoleSet) IsEmpty() bool {
+	for _, w := range rs.words {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (rs RoleSet) Equal(other RoleSet) bool {
+	return rs.HasAll(other) && other.HasAll(rs)
+}
+
+// Grant panics for a permission at or above MaxPermissions, input is checked by ParsePermission before
+func (rs *RoleSet) Grant(permissions ...Permission) {
+	words := rs.copyWords()
+	for _, p := range permissions {
+		if p >= MaxPermissions {
+			panic(fmt.Sprintf("auth: permission bit %d is out of range, the limit is %d", uint(p), MaxPermissions))
+		}
+		for int(p/64) >= len(words) {
+			words = append(words, 0)
+		}
+		words[p/64] |= 1 << (p % 64)
+	}
+	rs.words = words
+}
+
+func (rs *RoleSet) Revoke(permissions ...Permission) {
+	words := rs.copyWords()
+	for _, p := range permissions {
+		if int(p/64) < len(words) {
+			words[p/64] &^= 1 << (p % 64)
+		}
+	}
+	rs.words = trim(words)
+}
+
+// Union returns a new set with the permissions of both
+func (rs RoleSet) Union(other RoleSet) RoleSet {
+	n := len(rs.words)
+	if len(other.words) > n {
+		n = len(other.words)
+	}
+	words := make([]uint64, n)
+	for i := range words {
+		words[i] = rs.word(i) | other.word(i)
+	}
+	return RoleSet{words: trim(words)}
+}
+
+// Permissions lists the bits that are set, lowest first
+func (rs RoleSet) Permissions() []Permission {
+	var result []Permission
+	for i, w := range rs.words {
+		for w != 0 {
+			bit := bits.TrailingZeros64(w)
+			result = append(result, Permission(i*64+bit))
+			w &^= 1 << uint(bit)
+		}
+	}
+	return result
+}
+
+// CanSee reports whether the canSeeX permission of the continent is set. Being admin does not imply it
+func (rs RoleSet) CanSee(continent Continent) bool {
+	permission, ok := continent.permission()
+	return ok && rs.Has(permission)
+}
+
+// String formats the set as "admin|canSeeEurope". Unnamed bits are written as "bitN"
+func (rs RoleSet) String() string {
+	names := make([]string, 0, len(rs.words))
+	for _, p := range rs.Permissions() {
+		names = append(names, p.String())
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseRoleSet is the inverse of String. The empty string is the empty set
+func ParseRoleSet(text string) (RoleSet, error) {
+	var rs RoleSet
+	for _, part := range strings.Split(text, "|") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		permission, err := ParsePermission(part)
+		if err != nil {
+			return RoleSet{}, err
+		}
+		rs.Grant(permission)
+	}
+	return rs, nil
+}
+
+/*
+MarshalBinary writes the mask little endian, one byte per 8 permissions, without trailing zero bytes.
+A mask stored by the Roles byte type is therefore a valid one byte encoding of the same permissions
+*/
+func (rs RoleSet) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, len(rs.words)*8)
+	for _, w := range rs.words {
+		for i := uint(0); i < 64; i += 8 {
+			data = append(data, byte(w>>i))
+		}
+	}
+	for len(data) > 0 && data[len(data)-1] == 0 {
+		data = data[:len(data)-1]
+	}
+	return data, nil
+}
+
+func (rs *RoleSet) UnmarshalBinary(data []byte) error {
+	for len(data) > 0 && data[len(data)-1] == 0 {
+		data = data[:len(data)-1]
+	}
+	if len(data) > MaxPermissions/8 {
+		return fmt.Errorf("auth: role set of %d bytes is out of range, the limit is %d", len(data), MaxPermissions/8)
+	}
+	words := make([]uint64, (len(data)+7)/8)
+	for i, b := range data {
+		words[i/8] |= uint64(b) << (uint(i%8) * 8)
+	}
+	rs.words = trim(words)
+	return nil
+}
+
+func (rs RoleSet) MarshalText() ([]byte, error) {
+	return []byte(rs.String()), nil
+}
+
+func (rs *RoleSet) UnmarshalText(text []byte) error {
+	parsed, err := ParseRoleSet(string(text))
+	if err != nil {
+		return err
+	}
+	*rs = parsed
+	return nil
+}
+
+// UnmarshalJSON accepts the string form and also a number, which is how the Roles byte may have been stored
+func (rs *RoleSet) UnmarshalJSON(data []byte) error {
+	var mask uint64
+	if err := json.Unmarshal(data, &mask); err == nil {
+		*rs = RoleSetFromBits(mask)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("auth: role set must be a string or a number: %v", err)
+	}
+	return rs.UnmarshalText([]byte(text))
+}
+
+func (rs RoleSet) word(i int) uint64 {
+	if i < len(rs.words) {
+		return rs.words[i]
+	}
+	return 0
+}
+
+func (rs RoleSet) copyWords() []uint64 {
+	return append([]uint64(nil), rs.words...)
+}
+
+// trim drops zero words at the end, so equal sets have equal encodings
+func trim(words []uint64) []uint64 {
+	for len(words) > 0 && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return words
+}
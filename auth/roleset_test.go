@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRoleSet(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []Permission
+		wantErr bool
+	}{
+		{text: "", want: nil},
+		{text: "admin|canSeeEurope", want: []Permission{Admin, SeeEurope}},
+		{text: " ADMIN | canseeeurope ", want: []Permission{Admin, SeeEurope}},
+		{text: "bit100", want: []Permission{100}},
+		{text: "bit1023", want: []Permission{MaxPermissions - 1}},
+		{text: "bit1024", wantErr: true},
+		{text: "bit900000000000", wantErr: true},
+		{text: "bit99999999999999999999999", wantErr: true},
+		{text: "bit01", wantErr: true},
+		{text: "admin|nobody", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			rs, err := ParseRoleSet(test.text)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRoleSet(%q) = %v, want an error", test.text, rs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRoleSet(%q) error = %v", test.text, err)
+			}
+			if want := NewRoleSet(test.want...); !rs.Equal(want) {
+				t.Errorf("ParseRoleSet(%q) = %v, want %v", test.text, rs, want)
+			}
+		})
+	}
+}
+
+func TestRoleSetUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    RoleSet
+		wantErr bool
+	}{
+		{json: `"admin|canSeeAsia"`, want: NewRoleSet(Admin, SeeAsia)},
+		{json: `9`, want: NewRoleSet(Admin, SeeAsia)},
+		{json: `"bit900000000000"`, wantErr: true},
+		{json: `true`, wantErr: true},
+	}
+	for _, test := range tests {
+		var rs RoleSet
+		err := json.Unmarshal([]byte(test.json), &rs)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", test.json, err, test.wantErr)
+			continue
+		}
+		if err == nil && !rs.Equal(test.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", test.json, rs, test.want)
+		}
+	}
+}
+
+func TestRoleSetBinary(t *testing.T) {
+	for _, rs := range []RoleSet{{}, NewRoleSet(Admin), NewRoleSet(SeeAntarctica, 200), NewRoleSet(MaxPermissions - 1)} {
+		data, err := rs.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded RoleSet
+		if err := decoded.UnmarshalBinary(append(data, 0, 0)); err != nil || !decoded.Equal(rs) {
+			t.Errorf("UnmarshalBinary(MarshalBinary(%v)) = %v, %v", rs, decoded, err)
+		}
+	}
+	if err := new(RoleSet).UnmarshalBinary(make([]byte, MaxPermissions/8+1)); err != nil {
+		t.Errorf("trailing zero bytes are not permissions, got %v", err)
+	}
+	tooLong := make([]byte, MaxPermissions/8+1)
+	tooLong[len(tooLong)-1] = 1
+	if err := new(RoleSet).UnmarshalBinary(tooLong); err == nil {
+		t.Error("UnmarshalBinary accepted a bit above MaxPermissions")
+	}
+}
+
+func TestGrantDoesNotShareBackingArray(t *testing.T) {
+	original := NewRoleSet(Admin)
+	copied := original
+	copied.Grant(SeeEurope)
+	if original.Has(SeeEurope) {
+		t.Error("Grant on a copy changed the original")
+	}
+}
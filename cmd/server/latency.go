@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"firstApp/metrics"
+	"firstApp/server"
+	"firstApp/stats"
+)
+
+// latencyQuantiles are the quantiles published, the histogram of server.Metrics only has fixed buckets
+var latencyQuantiles = []float64{0.5, 0.9, 0.99}
+
+/*
+latencyWorkers aggregate the request durations in the background. The middleware only hands the duration to a
+channel, so a request never waits for the aggregation, and drops it when the workers are behind. Every worker has
+its own stats.Aggregator, the partial results are merged when /metrics is scraped
+*/
+type latencyWorkers struct {
+	dropped  int64 // first field for the alignment of atomic operations, see counter.Atomic
+	values   chan float64
+	partials []*stats.Aggregator
+}
+
+// startLatencyWorkers runs the workers until ctx is done and publishes their counts on registry
+func startLatencyWorkers(ctx context.Context, workers int, registry *metrics.Registry) *latencyWorkers {
+	lw := &latencyWorkers{values: make(chan float64, 1024), partials: make([]*stats.Aggregator, workers)}
+	for i := range lw.partials {
+		lw.partials[i] = stats.New(stats.Config{})
+		go lw.partials[i].Consume(ctx, lw.values)
+	}
+	registry.CounterFunc("stats_worker_values_total", "Request durations aggregated by each statistics worker.", []string{"worker"}, func(emit metrics.Emit) {
+		for i, partial := range lw.partials {
+			emit(float64(partial.Count()), strconv.Itoa(i))
+		}
+	})
+	registry.CounterFunc("stats_worker_values_dropped_total", "Request durations not aggregated because the workers were behind.", nil, func(emit metrics.Emit) {
+		emit(float64(atomic.LoadInt64(&lw.dropped)))
+	})
+	registry.GaugeFunc("http_request_duration_quantile_seconds", "Approximate quantiles of the HTTP request latency.", []string{"quantile"}, func(emit metrics.Emit) {
+		total := stats.New(stats.Config{})
+		for _, partial := range lw.partials {
+			total.Merge(partial)
+		}
+		if total.Count() == 0 {
+			return
+		}
+		for _, q := range latencyQuantiles {
+			emit(total.Quantile(q), strconv.FormatFloat(q, 'g', -1, 64))
+		}
+	})
+	return lw
+}
+
+func (lw *latencyWorkers) middleware() server.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(writer, request)
+			select {
+			case lw.values <- time.Since(start).Seconds():
+			default:
+				atomic.AddInt64(&lw.dropped, 1)
+			}
+		})
+	}
+}
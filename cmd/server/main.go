@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"firstApp/doctors"
+	"firstApp/logging"
+	"firstApp/metrics"
+	"firstApp/population"
+	"firstApp/server"
+)
+
+/*
+This used to panic when run twice, because ListenAndServe failed on the port that was already in use.
+Now the address and timeouts come from flags or SERVER_* variables, a failure to start is reported and
+SIGINT/SIGTERM stop the server after the in-flight requests are done. Try -addr :0 to get a free port
+*/
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	populationFile := flag.String("population-file", "", "CSV or JSON file to load the populations from")
+	populationOut := flag.String("population-out", "", "CSV or JSON file to save the populations to on shutdown, never the file they were loaded from")
+	populationHistory := flag.Int("population-history", population.DefaultMaxChanges, "entries of the population change log kept in memory")
+	config, err := server.ConfigFromFlags(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	logger := logging.New(logging.Config{})
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		logger.Close(ctx)
+	}()
+
+	registry := metrics.NewRegistry()
+	publishLoggerMetrics(registry, logger)
+	registry.GaugeFunc("go_goroutines", "Goroutines that currently exist.", nil, func(emit metrics.Emit) {
+		emit(float64(runtime.NumGoroutine()))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+	mux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("Hello Go!"))
+	})
+	mux.HandleFunc("/panic", func(writer http.ResponseWriter, request *http.Request) {
+		panic("something bad happened") //recovered by the middleware, the server keeps running
+	})
+	populations := population.NewStore(map[string]int{
+		"California": 39250018,
+		"Texas":      27232432,
+		"Florida":    20232432,
+		"Ohio":       11632432,
+		"Georgia":    10310371,
+	})
+	populations.SetHistoryLimits(population.HistoryLimits{MaxChanges: *populationHistory})
+	if *populationFile != "" {
+		rowErrors, err := populations.Load(*populationFile)
+		if err != nil {
+			logger.Error("cannot load populations", logging.F("file", *populationFile), logging.F("error", err))
+			return 1
+		}
+		for _, rowErr := range rowErrors {
+			logger.Warning("skipped population row", logging.F("file", *populationFile), logging.F("line", rowErr.Line), logging.F("error", rowErr.Message))
+		}
+	}
+	/*
+		Saving over the input would lose the skipped rows and the extra columns of the analyst's file,
+		so the output must be another file
+	*/
+	if *populationOut != "" {
+		if *populationFile != "" && sameFile(*populationFile, *populationOut) {
+			logger.Error("-population-out must not be the -population-file", logging.F("file", *populationOut))
+			return 2
+		}
+		defer func() {
+			if err := populations.Save(*populationOut); err != nil {
+				logger.Error("cannot save populations", logging.F("file", *populationOut), logging.F("error", err))
+			}
+		}()
+	}
+	population.NewHandler(populations).Register(mux)
+	doctors.NewHandler(doctors.NewStore(doctors.Doctor{Number: 3, ActorName: "John", Companions: []string{"Mike", "Jim"}})).Register(mux)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	latency := startLatencyWorkers(workersCtx, runtime.GOMAXPROCS(0), registry)
+
+	handler := server.Chain(server.Wrap(mux, logger), server.Metrics(registry, server.MuxRoute(mux)), latency.middleware())
+
+	//registered before Start, so a signal sent as soon as the port is open shuts down cleanly instead of killing us
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		sig := <-signals
+		logger.Info("shutting down", logging.F("signal", sig))
+		stop()
+	}()
+
+	srv := server.New(config, handler)
+	if err := srv.Start(); err != nil {
+		logger.Error("server did not start", logging.F("error", err))
+		return 1
+	}
+	logger.Info("server listening", logging.F("addr", srv.Addr()))
+
+	if err := srv.Wait(ctx); err != nil {
+		logger.Error("server stopped with an error", logging.F("error", err))
+		return 1
+	}
+	logger.Info("server stopped")
+	return 0
+}
+
+// publishLoggerMetrics exposes the entries logged and dropped by severity, read from the logger on every scrape
+func publishLoggerMetrics(registry *metrics.Registry, logger *logging.Logger) {
+	publish := func(counts func() map[logging.Severity]int64) func(metrics.Emit) {
+		return func(emit metrics.Emit) {
+			for severity, count := range counts() {
+				emit(float64(count), strings.ToLower(severity.String()))
+			}
+		}
+	}
+	registry.CounterFunc("log_entries_total", "Log entries accepted by the logger, by severity.", []string{"severity"}, publish(logger.Logged))
+	registry.CounterFunc("log_entries_dropped_total", "Log entries lost by the overflow policy or on close, by severity.", []string{"severity"}, publish(logger.Dropped))
+}
+
+// sameFile compares the files when both exist and the cleaned paths otherwise
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(infoA, infoB)
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,98 @@
+package counter
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Incrementer adds one and returns the new value
+type Incrementer interface {
+	Increment() int
+}
+
+/*
+Counter is an Incrementer that can be shared between goroutines. The three implementations give the same results
+and differ only in speed:
+
+	Atomic  one atomic integer, the default choice
+	Mutex   an int behind a mutex, easy to extend with more state under the same lock
+	Striped one atomic integer per stripe, for counters that many goroutines update much more often than they read
+*/
+type Counter interface {
+	Incrementer
+	Decrement() int
+	Add(delta int)
+	Value() int
+	// Reset sets the counter to zero and returns the value it had
+	Reset() int
+}
+
+var (
+	_ Counter = (*Atomic)(nil)
+	_ Counter = (*Mutex)(nil)
+	_ Counter = (*Striped)(nil)
+)
+
+// Atomic is a Counter on a single atomic integer. The zero value is ready to use
+type Atomic struct {
+	value int64 // first field, 64-bit atomic operations need 8-byte alignment on 32-bit platforms
+}
+
+func (c *Atomic) Increment() int {
+	return int(atomic.AddInt64(&c.value, 1))
+}
+
+func (c *Atomic) Decrement() int {
+	return int(atomic.AddInt64(&c.value, -1))
+}
+
+func (c *Atomic) Add(delta int) {
+	atomic.AddInt64(&c.value, int64(delta))
+}
+
+func (c *Atomic) Value() int {
+	return int(atomic.LoadInt64(&c.value))
+}
+
+func (c *Atomic) Reset() int {
+	return int(atomic.SwapInt64(&c.value, 0))
+}
+
+// Mutex is a Counter behind a sync.Mutex. The zero value is ready to use
+type Mutex struct {
+	mutex sync.Mutex
+	value int
+}
+
+func (c *Mutex) Increment() int {
+	return c.add(1)
+}
+
+func (c *Mutex) Decrement() int {
+	return c.add(-1)
+}
+
+func (c *Mutex) Add(delta int) {
+	c.add(delta)
+}
+
+func (c *Mutex) add(delta int) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.value += delta
+	return c.value
+}
+
+func (c *Mutex) Value() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.value
+}
+
+func (c *Mutex) Reset() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	value := c.value
+	c.value = 0
+	return value
+}
@@ -0,0 +1,99 @@
+package counter
+
+import (
+	"sync"
+	"testing"
+)
+
+var implementations = []struct {
+	name string
+	new  func() Counter
+}{
+	{"Atomic", func() Counter { return &Atomic{} }},
+	{"Mutex", func() Counter { return &Mutex{} }},
+	{"Striped", func() Counter { return NewStriped(0) }},
+	{"StripedZeroValue", func() Counter { return &Striped{} }},
+}
+
+func TestCounter(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	for _, implementation := range implementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			c := implementation.new()
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perGoroutine; i++ {
+						c.Increment()
+						c.Add(2)
+						c.Decrement()
+					}
+				}()
+			}
+			wg.Wait()
+			if got, want := c.Value(), goroutines*perGoroutine*2; got != want {
+				t.Errorf("Value() = %d, want %d", got, want)
+			}
+			if got, want := c.Reset(), goroutines*perGoroutine*2; got != want {
+				t.Errorf("Reset() = %d, want %d", got, want)
+			}
+			if got := c.Value(); got != 0 {
+				t.Errorf("Value() after Reset = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestIncrementReturnsNewValue(t *testing.T) {
+	for _, implementation := range implementations {
+		c := implementation.new()
+		if got := c.Increment(); got != 1 {
+			t.Errorf("%v: first Increment() = %d, want 1", implementation.name, got)
+		}
+		if got := c.Decrement(); got != 0 {
+			t.Errorf("%v: Decrement() = %d, want 0", implementation.name, got)
+		}
+	}
+}
+
+/*
+The benchmarks compare the counters under contention, every goroutine updates the same counter.
+Run with go test -bench . -cpu 1,4,16 ./counter, the Read variants make every 16th operation a Value
+*/
+func benchmarkCounter(b *testing.B, c Counter, readEvery int) {
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 1; pb.Next(); i++ {
+			if readEvery > 0 && i%readEvery == 0 {
+				c.Value()
+			} else {
+				c.Add(1)
+			}
+		}
+	})
+}
+
+func BenchmarkAtomic(b *testing.B) {
+	benchmarkCounter(b, &Atomic{}, 0)
+}
+
+func BenchmarkMutex(b *testing.B) {
+	benchmarkCounter(b, &Mutex{}, 0)
+}
+
+func BenchmarkStriped(b *testing.B) {
+	benchmarkCounter(b, NewStriped(0), 0)
+}
+
+func BenchmarkAtomicRead(b *testing.B) {
+	benchmarkCounter(b, &Atomic{}, 16)
+}
+
+func BenchmarkMutexRead(b *testing.B) {
+	benchmarkCounter(b, &Mutex{}, 16)
+}
+
+func BenchmarkStripedRead(b *testing.B) {
+	benchmarkCounter(b, NewStriped(0), 16)
+}
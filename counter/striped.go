@@ -0,0 +1,86 @@
+package counter
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const cacheLineSize = 64
+
+// stripe fills a whole cache line, so that two stripes never share one and the cores do not fight over it
+type stripe struct {
+	value int64
+	_     [cacheLineSize - 8]byte
+}
+
+/*
+Striped spreads the updates over several atomic integers. A goroutine takes its stripe from a sync.Pool,
+which keeps one per processor, so goroutines on different processors rarely touch the same stripe.
+Value sums all the stripes: it is exact when nobody is writing and it is a value the counter had at some point
+during the call otherwise. Increment and Decrement return Value, in hot paths use Add(1) and Add(-1).
+The zero value is ready to use, with one stripe per processor
+*/
+type Striped struct {
+	once    sync.Once
+	stripes []stripe
+	next    uint32
+	indexes sync.Pool
+}
+
+// NewStriped returns a Striped counter, stripes <= 0 means one per processor (GOMAXPROCS)
+func NewStriped(stripes int) *Striped {
+	c := &Striped{}
+	c.init(stripes)
+	return c
+}
+
+// init creates the stripes on first use, so that the zero value works. Later calls do nothing
+func (c *Striped) init(stripes int) {
+	c.once.Do(func() {
+		if stripes <= 0 {
+			stripes = runtime.GOMAXPROCS(0)
+		}
+		c.stripes = make([]stripe, stripes)
+		c.indexes.New = func() interface{} {
+			index := int(atomic.AddUint32(&c.next, 1)-1) % len(c.stripes)
+			return &index
+		}
+	})
+}
+
+func (c *Striped) Add(delta int) {
+	c.init(0)
+	index := c.indexes.Get().(*int)
+	atomic.AddInt64(&c.stripes[*index].value, int64(delta))
+	c.indexes.Put(index)
+}
+
+func (c *Striped) Increment() int {
+	c.Add(1)
+	return c.Value()
+}
+
+func (c *Striped) Decrement() int {
+	c.Add(-1)
+	return c.Value()
+}
+
+func (c *Striped) Value() int {
+	c.init(0)
+	var total int64
+	for i := range c.stripes {
+		total += atomic.LoadInt64(&c.stripes[i].value)
+	}
+	return int(total)
+}
+
+// Reset empties every stripe. An Add running at the same time is either in the returned value or in the counter
+func (c *Striped) Reset() int {
+	c.init(0)
+	var total int64
+	for i := range c.stripes {
+		total += atomic.SwapInt64(&c.stripes[i].value, 0)
+	}
+	return int(total)
+}
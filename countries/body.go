@@ -0,0 +1,122 @@
+package countries
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge is returned when a response is longer than Config.MaxBodyBytes once decompressed
+var ErrBodyTooLarge = errors.New("countries: response body too large")
+
+// excerptBytes is how much of an error body StatusError keeps
+const excerptBytes = 512
+
+/*
+StatusError is an answer outside 2xx. Body holds the start of what the server sent, which usually says why.
+A 404 is also ErrNotFound for errors.Is
+*/
+type StatusError struct {
+	StatusCode int
+	URL        string
+	Body       string
+	// Err is why the body could not be opened, a bad gzip header for example. Body is empty then
+	Err error
+}
+
+func (se *StatusError) Error() string {
+	message := fmt.Sprintf("countries: %v answered %d %v", se.URL, se.StatusCode, http.StatusText(se.StatusCode))
+	if se.Body != "" {
+		message += ": " + se.Body
+	}
+	if se.Err != nil {
+		message += fmt.Sprintf(" (body: %v)", se.Err)
+	}
+	return message
+}
+
+func (se *StatusError) Unwrap() error {
+	return se.Err
+}
+
+func (se *StatusError) Is(target error) bool {
+	return target == ErrNotFound && se.StatusCode == http.StatusNotFound
+}
+
+/*
+openBody returns the body decompressed if needed and limited to maxBytes. Reading past the limit fails with
+ErrBodyTooLarge instead of cutting the data short. The limit is on the decompressed size, so a small gzip
+body cannot expand without bound
+*/
+func openBody(response *http.Response, maxBytes int64) (io.ReadCloser, error) {
+	var body io.ReadCloser = response.Body
+	if strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("countries: gzip body: %w", err)
+		}
+		body = gz
+	}
+	return &limitedBody{ReadCloser: body, remaining: maxBytes}, nil
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (lb *limitedBody) Read(data []byte) (int, error) {
+	if lb.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(data)) > lb.remaining+1 {
+		data = data[:lb.remaining+1]
+	}
+	n, err := lb.ReadCloser.Read(data)
+	lb.remaining -= int64(n)
+	if lb.remaining < 0 {
+		return n + int(lb.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// excerpt reads the beginning of an error body. A body that cannot be read gives an empty excerpt
+func excerpt(body io.Reader) string {
+	data, _ := ioutil.ReadAll(io.LimitReader(body, excerptBytes))
+	text := strings.TrimSpace(string(data))
+	if len(data) == excerptBytes {
+		text += "..."
+	}
+	return text
+}
+
+/*
+decodeArray decodes a JSON array one element at a time, so the whole answer is never held in memory.
+fn is called for every element, an error from fn stops the decoding
+*/
+func decodeArray(body io.Reader, fn func(country Country) error) error {
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected a JSON array, got %v", token)
+	}
+	for decoder.More() {
+		var country Country
+		if err := decoder.Decode(&country); err != nil {
+			return err
+		}
+		if err := fn(country); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
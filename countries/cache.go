@@ -0,0 +1,278 @@
+package countries
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CacheMode int
+
+const (
+	// Cache serves fresh responses from the store and revalidates stale ones with their ETag
+	Cache CacheMode = iota
+	// Record always goes to the network and stores every response, whatever its headers say
+	Record
+	// Replay never goes to the network. A request without a stored response fails with ErrNoFixture
+	Replay
+)
+
+// ErrNoFixture is returned in Replay mode for a request that was never recorded
+var ErrNoFixture = errors.New("countries: no recorded response")
+
+// responses larger than this are passed through without being stored
+const maxCachedBody = 10 << 20
+
+/*
+CachingTransport is an http.RoundTripper that keeps GET responses in a CacheStore.
+In Cache mode it honours Cache-Control (max-age, no-cache, no-store) and Expires, and revalidates stale
+entries with If-None-Match, so a 304 costs no body. Responses get an X-Cache header: HIT, REVALIDATED,
+MISS or REPLAY
+*/
+type CachingTransport struct {
+	store CacheStore
+	mode  CacheMode
+	next  http.RoundTripper
+	now   func() time.Time
+}
+
+// NewCachingTransport wraps next, or http.DefaultTransport when next is nil
+func NewCachingTransport(store CacheStore, mode CacheMode, next http.RoundTripper) *CachingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CachingTransport{store: store, mode: mode, next: next, now: time.Now}
+}
+
+// Client is an http.Client using the transport, ready for Config.HTTPClient
+func (ct *CachingTransport) Client() *http.Client {
+	return &http.Client{Transport: ct}
+}
+
+// CacheKey is how requests are stored. The host is left out, so fixtures work behind any stand-in server
+func CacheKey(request *http.Request) string {
+	return request.Method + " " + request.URL.RequestURI()
+}
+
+func (ct *CachingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet {
+		if ct.mode == Replay {
+			return nil, fmt.Errorf("%w for %v", ErrNoFixture, CacheKey(request))
+		}
+		return ct.next.RoundTrip(request)
+	}
+	key := CacheKey(request)
+	cached, found, err := ct.store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("countries: cache: %v", err)
+	}
+
+	switch ct.mode {
+	case Replay:
+		if !found {
+			return nil, fmt.Errorf("%w for %v", ErrNoFixture, key)
+		}
+		return toResponse(cached, request, "REPLAY"), nil
+	case Record:
+		return ct.fetch(request, key, nil, true)
+	}
+
+	if found && ct.fresh(cached, request) {
+		return toResponse(cached, request, "HIT"), nil
+	}
+	if found && cached.Header.Get("ETag") != "" {
+		return ct.fetch(request, key, cached, false)
+	}
+	return ct.fetch(request, key, nil, false)
+}
+
+/*
+fetch goes to the network. With a stale entry it sends If-None-Match and a 304 refreshes the entry.
+always stores the response even if its headers forbid it, that is what Record needs
+*/
+func (ct *CachingTransport) fetch(request *http.Request, key string, stale *CachedResponse, always bool) (*http.Response, error) {
+	outgoing := request
+	if stale != nil {
+		outgoing = request.Clone(request.Context())
+		outgoing.Header.Set("If-None-Match", stale.Header.Get("ETag"))
+	}
+	response, err := ct.next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	if stale != nil && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		refreshed := *stale
+		refreshed.Header = stale.Header.Clone()
+		for _, name := range []string{"Cache-Control", "Expires", "ETag", "Date"} {
+			if value := response.Header.Get(name); value != "" {
+				refreshed.Header.Set(name, value)
+			}
+		}
+		refreshed.StoredAt = ct.now()
+		if err := ct.store.Set(key, &refreshed); err != nil {
+			return nil, fmt.Errorf("countries: cache: %v", err)
+		}
+		return toResponse(&refreshed, request, "REVALIDATED"), nil
+	}
+	if !always && !storable(request, response) {
+		response.Header.Set("X-Cache", "MISS")
+		return response, nil
+	}
+
+	/*
+		The body is not read here: it streams to the caller, under the caller's own size limit,
+		and a copy is stored once the caller has read all of it
+	*/
+	header := response.Header.Clone()
+	statusCode := response.StatusCode
+	response.Body = &teeBody{body: response.Body, store: func(body []byte) error {
+		cached := &CachedResponse{Key: key, StatusCode: statusCode, Header: header, Body: body, StoredAt: ct.now()}
+		if err := ct.store.Set(key, cached); err != nil {
+			return fmt.Errorf("countries: cache: %v", err)
+		}
+		return nil
+	}}
+	response.Header.Set("X-Cache", "MISS")
+	return response, nil
+}
+
+// drainOnClose is how much of the body Close still reads to store it, a JSON decoder stops before the final newline
+const drainOnClose = 4 << 10
+
+/*
+teeBody keeps a copy of what the caller reads and stores it at the end of the body. A body abandoned by the
+caller, cut by an error or longer than maxCachedBody is not stored
+*/
+type teeBody struct {
+	body     io.ReadCloser
+	buffer   bytes.Buffer
+	overflow bool
+	stored   bool
+	store    func(body []byte) error
+}
+
+func (tb *teeBody) Read(data []byte) (int, error) {
+	n, err := tb.body.Read(data)
+	tb.keep(data[:n])
+	if err == io.EOF {
+		if storeErr := tb.finish(); storeErr != nil {
+			return n, storeErr
+		}
+	}
+	return n, err
+}
+
+func (tb *teeBody) keep(data []byte) {
+	if tb.overflow {
+		return
+	}
+	if tb.buffer.Len()+len(data) > maxCachedBody {
+		tb.overflow = true
+		tb.buffer = bytes.Buffer{}
+		return
+	}
+	tb.buffer.Write(data)
+}
+
+func (tb *teeBody) finish() error {
+	if tb.stored || tb.overflow {
+		return nil
+	}
+	tb.stored = true
+	return tb.store(tb.buffer.Bytes())
+}
+
+func (tb *teeBody) Close() error {
+	if !tb.stored && !tb.overflow {
+		rest, err := ioutil.ReadAll(io.LimitReader(tb.body, drainOnClose+1))
+		if err == nil && len(rest) <= drainOnClose { //shorter than the limit, so the body ended
+			tb.keep(rest)
+			if storeErr := tb.finish(); storeErr != nil {
+				tb.body.Close()
+				return storeErr
+			}
+		}
+	}
+	return tb.body.Close()
+}
+
+// fresh follows max-age, then Expires. no-cache on either side means the entry must be revalidated
+func (ct *CachingTransport) fresh(cached *CachedResponse, request *http.Request) bool {
+	requestDirectives := cacheControl(request.Header)
+	responseDirectives := cacheControl(cached.Header)
+	if _, ok := requestDirectives["no-cache"]; ok {
+		return false
+	}
+	if _, ok := responseDirectives["no-cache"]; ok {
+		return false
+	}
+	age := ct.now().Sub(cached.StoredAt)
+	if maxAge, ok := responseDirectives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		return err == nil && age < time.Duration(seconds)*time.Second
+	}
+	if expires, err := http.ParseTime(cached.Header.Get("Expires")); err == nil {
+		return ct.now().Before(expires)
+	}
+	return false
+}
+
+// storable leaves out no-store and the statuses it makes no sense to cache
+func storable(request *http.Request, response *http.Response) bool {
+	if _, ok := cacheControl(request.Header)["no-store"]; ok {
+		return false
+	}
+	if _, ok := cacheControl(response.Header)["no-store"]; ok {
+		return false
+	}
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// cacheControl parses "max-age=60, no-cache" into {"max-age": "60", "no-cache": ""}
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if i := strings.Index(part, "="); i >= 0 {
+				name, value = part[:i], strings.Trim(part[i+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = value
+		}
+	}
+	return directives
+}
+
+func toResponse(cached *CachedResponse, request *http.Request, cacheStatus string) *http.Response {
+	header := cached.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("X-Cache", cacheStatus)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %v", cached.StatusCode, http.StatusText(cached.StatusCode)),
+		StatusCode:    cached.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       request,
+	}
+}
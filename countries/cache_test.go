@@ -0,0 +1,119 @@
+package countries
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// endlessBody is a JSON array that never ends, counting what was read from it
+type endlessBody struct {
+	read    int64
+	started bool
+}
+
+func (eb *endlessBody) Read(data []byte) (int, error) {
+	if !eb.started {
+		eb.started = true
+		return copy(data, "["), nil
+	}
+	for i := range data {
+		data[i] = ' '
+	}
+	eb.read += int64(len(data))
+	return len(data), nil
+}
+
+func (eb *endlessBody) Close() error {
+	return nil
+}
+
+// fakeTransport answers every request with the response built by answer and counts the requests
+type fakeTransport struct {
+	requests int32
+	answer   func() *http.Response
+}
+
+func (ft *fakeTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&ft.requests, 1)
+	response := ft.answer()
+	response.Request = request
+	return response, nil
+}
+
+func jsonResponse(body io.ReadCloser, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: body, ContentLength: -1}
+}
+
+func TestCacheRespectsClientBodyLimit(t *testing.T) {
+	body := &endlessBody{}
+	next := &fakeTransport{answer: func() *http.Response { return jsonResponse(body, nil) }}
+	store := NewMemoryStore()
+	client := NewClient(Config{
+		BaseURL:      "http://countries.test/v3.1",
+		HTTPClient:   NewCachingTransport(store, Record, next).Client(),
+		MaxBodyBytes: 1 << 10,
+	})
+	_, err := client.GetByName(context.Background(), "greece")
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("GetByName() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+	// the client limit plus what Close may drain, nowhere near the 10 MB the cache allows
+	if body.read > 1<<10+drainOnClose+64<<10 {
+		t.Errorf("%d bytes were read from the body, the client limit is 1 KB", body.read)
+	}
+	if _, found, _ := store.Get("GET /v3.1/name/greece"); found {
+		t.Error("a body cut by the client limit was stored")
+	}
+}
+
+func TestCacheStoresStreamedBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         CacheMode
+		header       http.Header
+		wantRequests int32
+	}{
+		{name: "fresh entry is a hit", mode: Cache, header: http.Header{"Cache-Control": {"max-age=60"}}, wantRequests: 1},
+		{name: "no-store is never stored", mode: Cache, header: http.Header{"Cache-Control": {"no-store"}}, wantRequests: 2},
+		{name: "record always goes to the network", mode: Record, header: http.Header{"Cache-Control": {"no-store"}}, wantRequests: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next := &fakeTransport{answer: func() *http.Response {
+				return jsonResponse(ioutil.NopCloser(strings.NewReader(greece+"\n")), test.header.Clone())
+			}}
+			store := NewMemoryStore()
+			client := NewClient(Config{BaseURL: "http://countries.test/v3.1", HTTPClient: NewCachingTransport(store, test.mode, next).Client()})
+			for i := 0; i < 2; i++ {
+				found, err := client.GetByName(context.Background(), "greece")
+				if err != nil || len(found) != 1 {
+					t.Fatalf("GetByName() = %v, %v", found, err)
+				}
+			}
+			if got := atomic.LoadInt32(&next.requests); got != test.wantRequests {
+				t.Errorf("%d requests went to the network, want %d", got, test.wantRequests)
+			}
+			cached, found, _ := store.Get("GET /v3.1/name/greece")
+			if test.mode == Record && (!found || string(cached.Body) != greece+"\n") {
+				t.Errorf("recorded body = %q, %v, want the whole body", cached, found)
+			}
+		})
+	}
+}
+
+func TestReplayWithoutFixture(t *testing.T) {
+	client := NewClient(Config{BaseURL: "http://countries.test/v3.1", HTTPClient: NewCachingTransport(NewMemoryStore(), Replay, nil).Client()})
+	if _, err := client.GetByName(context.Background(), "atlantis"); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("GetByName() error = %v, want %v", err, ErrNoFixture)
+	}
+}
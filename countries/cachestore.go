@@ -0,0 +1,153 @@
+package countries
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CachedResponse is what a CacheStore keeps for one request
+type CachedResponse struct {
+	Key        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+}
+
+// cachedJSON is the file format. Text bodies are kept as text so fixtures can be read and reviewed
+type cachedJSON struct {
+	Key        string      `json:"key"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       *string     `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"bodyBase64,omitempty"`
+	StoredAt   time.Time   `json:"storedAt"`
+}
+
+func (cr CachedResponse) MarshalJSON() ([]byte, error) {
+	file := cachedJSON{Key: cr.Key, StatusCode: cr.StatusCode, Header: cr.Header, StoredAt: cr.StoredAt}
+	if utf8.Valid(cr.Body) {
+		body := string(cr.Body)
+		file.Body = &body
+	} else {
+		file.BodyBase64 = cr.Body
+	}
+	return json.Marshal(file)
+}
+
+func (cr *CachedResponse) UnmarshalJSON(data []byte) error {
+	var file cachedJSON
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	*cr = CachedResponse{Key: file.Key, StatusCode: file.StatusCode, Header: file.Header, Body: file.BodyBase64, StoredAt: file.StoredAt}
+	if file.Body != nil {
+		cr.Body = []byte(*file.Body)
+	}
+	return nil
+}
+
+// CacheStore keeps responses by key. The key is built by the transport from the method and the URL
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool, error)
+	Set(key string, response *CachedResponse) error
+}
+
+// MemoryStore is a CacheStore in a map, safe for concurrent use
+type MemoryStore struct {
+	mutex     sync.RWMutex
+	responses map[string]*CachedResponse
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{responses: map[string]*CachedResponse{}}
+}
+
+func (ms *MemoryStore) Get(key string) (*CachedResponse, bool, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	response, ok := ms.responses[key]
+	return response, ok, nil
+}
+
+func (ms *MemoryStore) Set(key string, response *CachedResponse) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.responses[key] = response
+	return nil
+}
+
+/*
+DiskStore keeps one JSON file per response in a directory, which makes it usable for fixtures checked
+into the repository. File names start with the readable part of the key and end with a hash of it
+*/
+type DiskStore struct {
+	dir string
+}
+
+func NewDiskStore(dir string) *DiskStore {
+	return &DiskStore{dir: dir}
+}
+
+func (ds *DiskStore) Get(key string) (*CachedResponse, bool, error) {
+	data, err := ioutil.ReadFile(ds.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var response CachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false, err
+	}
+	return &response, true, nil
+}
+
+// Set writes a temporary file and renames it, so readers never see half a response
+func (ds *DiskStore) Set(key string, response *CachedResponse) error {
+	if err := os.MkdirAll(ds.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(ds.dir, ".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), ds.path(key))
+}
+
+func (ds *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	readable := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, key)
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	return filepath.Join(ds.dir, readable+"-"+hex.EncodeToString(sum[:6])+".json")
+}
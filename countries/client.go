@@ -0,0 +1,275 @@
+package countries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the successor of restcountries.eu, which is gone
+const DefaultBaseURL = "https://restcountries.com/v3.1"
+
+// ErrNotFound is returned (as a *StatusError with code 404) when no country matches the name
+var ErrNotFound = errors.New("countries: not found")
+
+// Config of a CountryClient. Zero fields get the defaults of NewClient
+type Config struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Timeout of a single attempt, retries included it is at most (MaxRetries+1) times longer
+	Timeout time.Duration
+	// MaxRetries is how many times a 5xx answer or a network error is retried. Negative means no retries
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, doubled every time up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxBodyBytes limits the size of a response once decompressed. Defaults to 5 MB
+	MaxBodyBytes int64
+}
+
+/*
+CountryClient replaces runResourceRequest: it returns errors instead of calling log.Fatal, decodes the
+answer into Country values and retries what is worth retrying
+*/
+type CountryClient struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxBody    int64
+	randMutex  sync.Mutex
+	random     *rand.Rand
+}
+
+func NewClient(config Config) *CountryClient {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = 200 * time.Millisecond
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 5 * time.Second
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = 5 << 20
+	}
+	return &CountryClient{
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		httpClient: config.HTTPClient,
+		timeout:    config.Timeout,
+		maxRetries: config.MaxRetries,
+		baseDelay:  config.BaseDelay,
+		maxDelay:   config.MaxDelay,
+		maxBody:    config.MaxBodyBytes,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// GetByName returns the countries whose name contains name, ErrNotFound if there is none
+func (c *CountryClient) GetByName(ctx context.Context, name string) ([]Country, error) {
+	var result []Country
+	err := c.get(ctx, "/name/"+url.PathEscape(name), func(body io.Reader) error {
+		result = result[:0] //a retried attempt starts over
+		return decodeArray(body, func(country Country) error {
+			result = append(result, country)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+/*
+EachByName is GetByName without building the slice: fn gets every country as soon as it is decoded.
+Once fn has been called the request is not retried any more, so fn never sees a country twice
+*/
+func (c *CountryClient) EachByName(ctx context.Context, name string, fn func(country Country) error) error {
+	delivered := false
+	return c.get(ctx, "/name/"+url.PathEscape(name), func(body io.Reader) error {
+		if delivered {
+			return errors.New("countries: cannot retry, part of the answer was already delivered")
+		}
+		return decodeArray(body, func(country Country) error {
+			delivered = true
+			return fn(country)
+		})
+	})
+}
+
+// get retries with backoff until an attempt succeeds, fails for good, or ctx ends
+func (c *CountryClient) get(ctx context.Context, path string, decode func(body io.Reader) error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = c.attempt(ctx, c.baseURL+path, decode)
+		if err == nil || !retryable(err) || attempt >= c.maxRetries {
+			return err
+		}
+		delay := c.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("countries: giving up after %d attempts: %w", attempt+1, err)
+		case <-timer.C:
+		}
+	}
+}
+
+/*
+attempt is one request. The duration is the Retry-After of a 503 or 429, if the server sent one.
+A failure to read the body (connection reset, the attempt timeout firing while streaming) is a readError and is
+retried. Invalid JSON, a body too large or an error from the caller is a decodeError and is not: the body arrived,
+and reading it again will not change it
+*/
+func (c *CountryClient) attempt(ctx context.Context, target string, decode func(body io.Reader) error) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Accept-Encoding", "gzip") //set by hand, so decompression is ours and under the size limit
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	raw := &readTracker{ReadCloser: response.Body}
+	response.Body = raw
+	body, err := openBody(response, c.maxBody)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		//the status says more than a body we could not open, so that error is only attached
+		statusErr := &StatusError{StatusCode: response.StatusCode, URL: target, Err: err}
+		if err == nil {
+			defer body.Close()
+			statusErr.Body = excerpt(body)
+		}
+		return retryAfter(response), statusErr
+	}
+	if err != nil {
+		if raw.err != nil {
+			return 0, &readError{url: target, err: raw.err}
+		}
+		return 0, err
+	}
+	defer body.Close()
+	if err := decode(body); err != nil {
+		if raw.err != nil {
+			return 0, &readError{url: target, err: raw.err}
+		}
+		return 0, &decodeError{url: target, err: err}
+	}
+	return 0, nil
+}
+
+// readTracker remembers the first error of the connection itself, io.EOF excluded
+type readTracker struct {
+	io.ReadCloser
+	err error
+}
+
+func (rt *readTracker) Read(data []byte) (int, error) {
+	n, err := rt.ReadCloser.Read(data)
+	if err != nil && err != io.EOF && rt.err == nil {
+		rt.err = err
+	}
+	return n, err
+}
+
+type readError struct {
+	url string
+	err error
+}
+
+func (re *readError) Error() string {
+	return fmt.Sprintf("countries: reading %v: %v", re.url, re.err)
+}
+
+func (re *readError) Unwrap() error {
+	return re.err
+}
+
+type decodeError struct {
+	url string
+	err error
+}
+
+func (de *decodeError) Error() string {
+	return fmt.Sprintf("countries: decoding %v: %v", de.url, de.err)
+}
+
+func (de *decodeError) Unwrap() error {
+	return de.err
+}
+
+/*
+retryable is true for 5xx, 429 and network errors, the ones while reading the body included. Other 4xx,
+decoding errors, a missing fixture and a cancelled context will not get better by trying again
+*/
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var readErr *readError
+	if errors.As(err, &readErr) {
+		return !errors.Is(err, context.Canceled)
+	}
+	var decodeErr *decodeError
+	if errors.As(err, &decodeErr) || errors.Is(err, context.Canceled) || errors.Is(err, ErrNoFixture) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// backoff is "full jitter": a random delay up to BaseDelay * 2^attempt, capped at MaxDelay
+func (c *CountryClient) backoff(attempt int) time.Duration {
+	ceiling := c.maxDelay
+	if attempt < 30 && c.baseDelay<<uint(attempt) < ceiling {
+		ceiling = c.baseDelay << uint(attempt)
+	}
+	c.randMutex.Lock()
+	defer c.randMutex.Unlock()
+	return time.Duration(c.random.Int63n(int64(ceiling) + 1))
+}
+
+func retryAfter(response *http.Response) time.Duration {
+	if response.StatusCode != http.StatusServiceUnavailable && response.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,193 @@
+package countries
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const greece = `[{"name":{"common":"Greece","official":"Hellenic Republic"},"capital":["Athens"],"population":10715549}]`
+
+// cutConnection writes the headers and half of the body, then drops the connection
+func cutConnection(t *testing.T, writer http.ResponseWriter) {
+	hijacker, ok := writer.(http.Hijacker)
+	if !ok {
+		t.Fatal("the test server cannot hijack connections")
+	}
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(buffer, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(greece), greece[:len(greece)/2])
+	buffer.Flush()
+	conn.Close()
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		firstAnswer  func(t *testing.T, writer http.ResponseWriter)
+		wantErr      bool
+		wantRequests int32
+	}{
+		{
+			name:         "connection cut in the middle of the body",
+			firstAnswer:  cutConnection,
+			wantRequests: 2,
+		},
+		{
+			name: "attempt timeout while streaming",
+			firstAnswer: func(t *testing.T, writer http.ResponseWriter) {
+				writer.Write([]byte(greece[:10]))
+				writer.(http.Flusher).Flush()
+				time.Sleep(300 * time.Millisecond)
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "server error",
+			firstAnswer: func(t *testing.T, writer http.ResponseWriter) {
+				http.Error(writer, "try later", http.StatusBadGateway)
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "invalid JSON is final",
+			firstAnswer: func(t *testing.T, writer http.ResponseWriter) {
+				writer.Write([]byte(`[{"name": 42}]`))
+			},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name: "not found is final",
+			firstAnswer: func(t *testing.T, writer http.ResponseWriter) {
+				http.Error(writer, `{"status":404,"message":"Not Found"}`, http.StatusNotFound)
+			},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					test.firstAnswer(t, writer)
+					return
+				}
+				writer.Write([]byte(greece))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{BaseURL: server.URL, Timeout: 100 * time.Millisecond, BaseDelay: time.Millisecond})
+			found, err := client.GetByName(context.Background(), "greece")
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("GetByName() = %v, want an error", found)
+				}
+			} else if err != nil || len(found) != 1 || found[0].Name.Common != "Greece" {
+				t.Errorf("GetByName() = %v, %v, want Greece", found, err)
+			}
+			if got := atomic.LoadInt32(&requests); got != test.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, test.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err := NewClient(Config{BaseURL: server.URL}).GetByName(context.Background(), "atlantis")
+	var statusErr *StatusError
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetByName() error = %v, want a 404 StatusError", err)
+	}
+}
+
+func gzipped(t *testing.T, text string) []byte {
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	if _, err := gz.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestClientBody(t *testing.T) {
+	padded := `[` + strings.Repeat(" ", 10000) + greece[1:]
+	tests := []struct {
+		name     string
+		gzip     bool
+		body     string
+		maxBytes int64
+		wantErr  error
+	}{
+		{"plain", false, greece, 0, nil},
+		{"gzip", true, greece, 0, nil},
+		{"at the limit", false, greece, int64(len(greece)), nil},
+		{"over the limit", false, greece, int64(len(greece)) - 1, ErrBodyTooLarge},
+		{"gzip under the limit once compressed, over it once decompressed", true, padded, 1000, ErrBodyTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if request.Header.Get("Accept-Encoding") != "gzip" {
+					t.Errorf("Accept-Encoding = %q, want gzip", request.Header.Get("Accept-Encoding"))
+				}
+				if test.gzip {
+					writer.Header().Set("Content-Encoding", "gzip")
+					writer.Write(gzipped(t, test.body))
+					return
+				}
+				writer.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{BaseURL: server.URL, MaxBodyBytes: test.maxBytes, BaseDelay: time.Millisecond})
+			found, err := client.GetByName(context.Background(), "greece")
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("GetByName() error = %v, want %v", err, test.wantErr)
+				}
+				if got := atomic.LoadInt32(&requests); got != 1 {
+					t.Errorf("server got %d requests, a body too large should not be retried", got)
+				}
+			} else if err != nil || len(found) != 1 || found[0].Name.Common != "Greece" {
+				t.Errorf("GetByName() = %v, %v, want Greece", found, err)
+			}
+		})
+	}
+}
+
+func TestClientBadGzipKeepsTheStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Encoding", "gzip")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		writer.Write([]byte("down for maintenance"))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(Config{BaseURL: server.URL, MaxRetries: -1}).GetByName(context.Background(), "greece")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetByName() error = %v, want a 503 StatusError", err)
+	}
+	if !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("StatusError.Err = %v, want the gzip error", statusErr.Err)
+	}
+}
@@ -0,0 +1,27 @@
+package countries
+
+// Country is the part of the restcountries v3.1 answer we use
+type Country struct {
+	Name       Name                `json:"name"`
+	CCA2       string              `json:"cca2"`
+	CCA3       string              `json:"cca3"`
+	Capital    []string            `json:"capital"`
+	Region     string              `json:"region"`
+	Subregion  string              `json:"subregion"`
+	Population int64               `json:"population"`
+	Area       float64             `json:"area"`
+	LatLng     []float64           `json:"latlng"`
+	Timezones  []string            `json:"timezones"`
+	Languages  map[string]string   `json:"languages"`
+	Currencies map[string]Currency `json:"currencies"`
+}
+
+type Name struct {
+	Common   string `json:"common"`
+	Official string `json:"official"`
+}
+
+type Currency struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
@@ -0,0 +1,70 @@
+package countries
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const fixturesDir = "testdata/fixtures"
+
+/*
+newFixtureServer is a stand-in for restcountries that answers with the responses recorded in store (see Record).
+Point Config.BaseURL at server.URL + "/v3.1" and the client runs without network
+*/
+func newFixtureServer(t *testing.T, store CacheStore) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		cached, found, err := store.Get(CacheKey(request))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(writer, "no fixture for "+CacheKey(request), http.StatusNotFound)
+			return
+		}
+		for name, values := range cached.Header {
+			if name == "Content-Length" || name == "Transfer-Encoding" {
+				continue
+			}
+			writer.Header()[name] = values
+		}
+		writer.Header().Set("X-Cache", "FIXTURE")
+		writer.WriteHeader(cached.StatusCode)
+		writer.Write(cached.Body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures := newFixtureServer(t, NewDiskStore(fixturesDir))
+	clients := []struct {
+		name   string
+		config Config
+	}{
+		{"fixture server", Config{BaseURL: fixtures.URL + "/v3.1"}},
+		{"fixture server behind the cache", Config{
+			BaseURL:    fixtures.URL + "/v3.1",
+			HTTPClient: NewCachingTransport(NewMemoryStore(), Cache, nil).Client(),
+		}},
+		{"replay", Config{HTTPClient: NewCachingTransport(NewDiskStore(fixturesDir), Replay, nil).Client()}},
+	}
+	for _, c := range clients {
+		t.Run(c.name, func(t *testing.T) {
+			client := NewClient(c.config)
+			result, err := client.GetByName(context.Background(), "greece")
+			if err != nil {
+				t.Fatalf("GetByName(greece) error = %v", err)
+			}
+			if len(result) != 1 || result[0].CCA3 != "GRC" || result[0].Name.Common != "Greece" {
+				t.Errorf("GetByName(greece) = %+v, want Greece (GRC)", result)
+			}
+			if _, err := client.GetByName(context.Background(), "atlantis"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetByName(atlantis) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package doctors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"firstApp/server"
+	"firstApp/validation"
+)
+
+/*
+Handler serves the registry under /doctors:
+
+	GET, POST                /doctors
+	GET, PUT, PATCH, DELETE  /doctors/{n}
+	GET, POST                /doctors/{n}/companions
+	DELETE                   /doctors/{n}/companions/{name}
+
+Every body is checked with the validate tags of Doctor before it reaches the store
+*/
+type Handler struct {
+	store *Store
+}
+
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+// Register mounts the handler on a mux, for both /doctors and everything below it
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/doctors", h)
+	mux.Handle("/doctors/", h)
+}
+
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	if parts[0] != "doctors" {
+		server.WriteError(writer, http.StatusNotFound, "not found")
+		return
+	}
+	if len(parts) == 1 {
+		h.serveCollection(writer, request)
+		return
+	}
+	number, err := strconv.Atoi(parts[1])
+	if err != nil {
+		server.WriteError(writer, http.StatusNotFound, "doctor number must be an integer")
+		return
+	}
+	switch {
+	case len(parts) == 2:
+		h.serveDoctor(writer, request, number)
+	case len(parts) == 3 && parts[2] == "companions":
+		h.serveCompanions(writer, request, number)
+	case len(parts) == 4 && parts[2] == "companions":
+		h.serveCompanion(writer, request, number, parts[3])
+	default:
+		server.WriteError(writer, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) serveCollection(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		server.WriteJSON(writer, http.StatusOK, h.store.List())
+	case http.MethodPost:
+		var d Doctor
+		if !decodeValid(writer, request, &d) {
+			return
+		}
+		if err := h.store.Create(d); err != nil {
+			writeStoreError(writer, err)
+			return
+		}
+		writer.Header().Set("Location", "/doctors/"+strconv.Itoa(d.Number))
+		server.WriteJSON(writer, http.StatusCreated, d)
+	default:
+		server.MethodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+// patch has pointers so that a missing field can be told apart from an empty one
+type patch struct {
+	ActorName  *string   `json:"actorName"`
+	Companions *[]string `json:"companions"`
+}
+
+func (h *Handler) serveDoctor(writer http.ResponseWriter, request *http.Request, number int) {
+	switch request.Method {
+	case http.MethodGet:
+		d, ok := h.store.Get(number)
+		if !ok {
+			writeStoreError(writer, ErrNotFound)
+			return
+		}
+		server.WriteJSON(writer, http.StatusOK, d)
+	case http.MethodPut:
+		var d Doctor
+		if err := server.DecodeJSON(writer, request, &d); err != nil {
+			server.WriteError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+		if d.Number != 0 && d.Number != number {
+			server.WriteError(writer, http.StatusBadRequest, "number in the body does not match the URL")
+			return
+		}
+		d.Number = number
+		if err := validation.Validate(d); err != nil {
+			server.WriteValidationError(writer, request, err)
+			return
+		}
+		if err := h.store.Replace(d); err != nil {
+			writeStoreError(writer, err)
+			return
+		}
+		server.WriteJSON(writer, http.StatusOK, d)
+	case http.MethodPatch:
+		var p patch
+		if err := server.DecodeJSON(writer, request, &p); err != nil {
+			server.WriteError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.update(writer, request, number, func(d *Doctor) error {
+			if p.ActorName != nil {
+				d.ActorName = *p.ActorName
+			}
+			if p.Companions != nil {
+				d.Companions = *p.Companions
+			}
+			return nil
+		})
+	case http.MethodDelete:
+		if err := h.store.Delete(number); err != nil {
+			writeStoreError(writer, err)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		server.MethodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+type companion struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+func (h *Handler) serveCompanions(writer http.ResponseWriter, request *http.Request, number int) {
+	switch request.Method {
+	case http.MethodGet:
+		d, ok := h.store.Get(number)
+		if !ok {
+			writeStoreError(writer, ErrNotFound)
+			return
+		}
+		companions := d.Companions
+		if companions == nil {
+			companions = []string{}
+		}
+		server.WriteJSON(writer, http.StatusOK, companions)
+	case http.MethodPost:
+		var c companion
+		if !decodeValid(writer, request, &c) {
+			return
+		}
+		h.update(writer, request, number, func(d *Doctor) error {
+			for _, existing := range d.Companions {
+				if existing == c.Name {
+					return ErrCompanionExists
+				}
+			}
+			d.Companions = append(d.Companions, c.Name)
+			return nil
+		})
+	default:
+		server.MethodNotAllowed(writer, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (h *Handler) serveCompanion(writer http.ResponseWriter, request *http.Request, number int, name string) {
+	if request.Method != http.MethodDelete {
+		server.MethodNotAllowed(writer, http.MethodDelete)
+		return
+	}
+	_, err := h.store.Update(number, func(d *Doctor) error {
+		for i, existing := range d.Companions {
+			if existing == name {
+				d.Companions = append(d.Companions[:i], d.Companions[i+1:]...)
+				return nil
+			}
+		}
+		return ErrNoCompanion
+	})
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// update applies change under the store lock and stores the result only if it is still valid
+func (h *Handler) update(writer http.ResponseWriter, request *http.Request, number int, change func(d *Doctor) error) {
+	d, err := h.store.Update(number, func(d *Doctor) error {
+		if err := change(d); err != nil {
+			return err
+		}
+		return validation.Validate(d)
+	})
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		server.WriteValidationError(writer, request, err)
+		return
+	}
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+	server.WriteJSON(writer, http.StatusOK, d)
+}
+
+func decodeValid(writer http.ResponseWriter, request *http.Request, value interface{}) bool {
+	if err := server.DecodeJSON(writer, request, value); err != nil {
+		server.WriteError(writer, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if err := validation.Validate(value); err != nil {
+		server.WriteValidationError(writer, request, err)
+		return false
+	}
+	return true
+}
+
+func writeStoreError(writer http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound, ErrNoCompanion:
+		server.WriteError(writer, http.StatusNotFound, err.Error())
+	case ErrExists, ErrCompanionExists:
+		server.WriteError(writer, http.StatusConflict, err.Error())
+	default:
+		server.WriteError(writer, http.StatusInternalServerError, err.Error())
+	}
+}
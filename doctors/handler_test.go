@@ -0,0 +1,72 @@
+package doctors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// the steps run in order against the same store, each one sees what the previous ones did
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewStore(Doctor{Number: 3, ActorName: "John", Companions: []string{"Mike"}})).Register(mux)
+	steps := []struct {
+		method, path, body string
+		status             int
+		contains           string
+	}{
+		{"GET", "/doctors", "", 200, `"actorName":"John"`},
+		{"GET", "/doctors/3", "", 200, `"companions":["Mike"]`},
+		{"GET", "/doctors/4", "", 404, "doctor not found"},
+		{"GET", "/doctors/x", "", 404, "must be an integer"},
+		{"POST", "/doctors", `{"number":4,"actorName":"Tom","companions":[]}`, 201, `"number":4`},
+		{"POST", "/doctors", `{"number":4,"actorName":"Tom"}`, 409, "already exists"},
+		{"POST", "/doctors", `{"number":5,"actorName":""}`, 422, "ActorName is required"},
+		{"POST", "/doctors", `{"number":5,"actorName":"Tom","extra":1}`, 400, "invalid JSON"},
+		{"PUT", "/doctors/4", `{"number":5,"actorName":"Tom"}`, 400, "does not match"},
+		{"PUT", "/doctors/4", `{"actorName":"Peter"}`, 200, `"actorName":"Peter"`},
+		{"PATCH", "/doctors/3", `{"companions":["Mike","Mike"]}`, 422, "must not contain duplicates"},
+		{"PATCH", "/doctors/3", `{"actorName":"Jon"}`, 200, `"companions":["Mike"]`},
+		{"POST", "/doctors/3/companions", `{"name":"Jim"}`, 200, `["Mike","Jim"]`},
+		{"POST", "/doctors/3/companions", `{"name":"Jim"}`, 409, "companion already exists"},
+		{"GET", "/doctors/3/companions", "", 200, `["Mike","Jim"]`},
+		{"DELETE", "/doctors/3/companions/Mike", "", 204, ""},
+		{"DELETE", "/doctors/3/companions/Mike", "", 404, "companion not found"},
+		{"GET", "/doctors/4/companions", "", 200, "[]"},
+		{"DELETE", "/doctors/4", "", 204, ""},
+		{"DELETE", "/doctors/4", "", 404, "doctor not found"},
+		{"HEAD", "/doctors", "", 405, ""},
+		{"GET", "/doctors/3/other", "", 404, "not found"},
+	}
+	for _, step := range steps {
+		request := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, request)
+		if recorder.Code != step.status || !strings.Contains(recorder.Body.String(), step.contains) {
+			t.Errorf("%v %v: %d %s, want %d containing %q", step.method, step.path, recorder.Code, recorder.Body.String(), step.status, step.contains)
+		}
+	}
+}
+
+func TestStoreCopies(t *testing.T) {
+	companions := []string{"Mike"}
+	store := NewStore(Doctor{Number: 1, ActorName: "John", Companions: companions})
+	companions[0] = "changed by the caller"
+	d, _ := store.Get(1)
+	d.Companions[0] = "changed again"
+	if got, _ := store.Get(1); got.Companions[0] != "Mike" {
+		t.Errorf("the store shares its slice: companion is %q", got.Companions[0])
+	}
+	_, err := store.Update(1, func(d *Doctor) error {
+		d.Number = 2
+		d.Companions = append(d.Companions, "Jim")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := store.Get(1); !ok || len(got.Companions) != 2 {
+		t.Errorf("Update changed the number or lost the change: %+v", got)
+	}
+}
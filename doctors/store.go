@@ -0,0 +1,118 @@
+package doctors
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+/*
+Doctor is an entry of the registry. Number identifies it in the URL (/doctors/3)
+*/
+type Doctor struct {
+	Number     int      `json:"number" validate:"min=1"`
+	ActorName  string   `json:"actorName" validate:"required,max=100"`
+	Companions []string `json:"companions" validate:"unique,dive,required,max=100"` //dive: the rules after it apply to every companion
+}
+
+var (
+	ErrNotFound        = errors.New("doctor not found")
+	ErrExists          = errors.New("doctor already exists")
+	ErrCompanionExists = errors.New("companion already exists")
+	ErrNoCompanion     = errors.New("companion not found")
+)
+
+/*
+Store keeps the doctors in memory and is safe for concurrent use. Doctors go in and out as copies:
+Companions is a slice, and sharing it would let a caller change the store without the lock
+*/
+type Store struct {
+	mutex   sync.RWMutex
+	doctors map[int]Doctor
+}
+
+func NewStore(doctors ...Doctor) *Store {
+	s := &Store{doctors: make(map[int]Doctor, len(doctors))}
+	for _, d := range doctors {
+		s.doctors[d.Number] = clone(d)
+	}
+	return s
+}
+
+// List returns every doctor ordered by Number
+func (s *Store) List() []Doctor {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make([]Doctor, 0, len(s.doctors))
+	for _, d := range s.doctors {
+		result = append(result, clone(d))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Number < result[j].Number
+	})
+	return result
+}
+
+func (s *Store) Get(number int) (Doctor, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	d, ok := s.doctors[number]
+	return clone(d), ok
+}
+
+func (s *Store) Create(d Doctor) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.doctors[d.Number]; ok {
+		return ErrExists
+	}
+	s.doctors[d.Number] = clone(d)
+	return nil
+}
+
+func (s *Store) Replace(d Doctor) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.doctors[d.Number]; !ok {
+		return ErrNotFound
+	}
+	s.doctors[d.Number] = clone(d)
+	return nil
+}
+
+/*
+Update changes a doctor in place under the lock. fn gets a copy; if it returns an error nothing is stored.
+The number cannot be changed
+*/
+func (s *Store) Update(number int, fn func(d *Doctor) error) (Doctor, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	d, ok := s.doctors[number]
+	if !ok {
+		return Doctor{}, ErrNotFound
+	}
+	d = clone(d)
+	if err := fn(&d); err != nil {
+		return Doctor{}, err
+	}
+	d.Number = number
+	s.doctors[number] = d
+	return clone(d), nil
+}
+
+func (s *Store) Delete(number int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.doctors[number]; !ok {
+		return ErrNotFound
+	}
+	delete(s.doctors, number)
+	return nil
+}
+
+func clone(d Doctor) Doctor {
+	if d.Companions != nil {
+		d.Companions = append([]string(nil), d.Companions...)
+	}
+	return d
+}
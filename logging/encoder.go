@@ -0,0 +1,172 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+Encoder turns an entry into a single line, trailing newline included. Sinks that write bytes use an Encoder,
+so the same sink can produce text for humans or JSON for the log shipper
+*/
+type Encoder interface {
+	Encode(entry Entry) ([]byte, error)
+}
+
+// TextLayout is the timestamp layout of the original "time - [SEVERITY]message" logger
+const TextLayout = "2006-01-02T15:04:05"
+
+/*
+TextEncoder writes "time - [SEVERITY]message key=value". Control characters in the message are escaped,
+so a message can never start a new line on its own.
+TimeLayout defaults to TextLayout and Location to the local time zone of the entry
+*/
+type TextEncoder struct {
+	TimeLayout string
+	Location   *time.Location
+}
+
+func (te TextEncoder) Encode(entry Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(formatTime(entry.Time, te.TimeLayout, TextLayout, te.Location))
+	buf.WriteString(" - [")
+	buf.WriteString(entry.Severity.String())
+	buf.WriteString("]")
+	buf.WriteString(escapeControl(entry.Message))
+	for _, f := range entry.Fields {
+		buf.WriteByte(' ')
+		writeLogfmtPair(&buf, f.Key, fmt.Sprint(f.Value))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+/*
+JSONEncoder writes one JSON object per line: {"time":...,"severity":...,"message":...} followed by the fields
+in the order they were given. TimeLayout defaults to time.RFC3339Nano
+*/
+type JSONEncoder struct {
+	TimeLayout string
+	Location   *time.Location
+}
+
+func (je JSONEncoder) Encode(entry Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	writeJSON(&buf, formatTime(entry.Time, je.TimeLayout, time.RFC3339Nano, je.Location))
+	buf.WriteString(`,"severity":`)
+	writeJSON(&buf, entry.Severity.String())
+	buf.WriteString(`,"message":`)
+	writeJSON(&buf, entry.Message)
+	for _, f := range entry.Fields {
+		buf.WriteByte(',')
+		writeJSON(&buf, f.Key)
+		buf.WriteByte(':')
+		writeJSON(&buf, f.Value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+/*
+LogfmtEncoder writes time=... severity=... message=... key=value. Values with spaces, quotes, '=' or control
+characters are quoted. TimeLayout defaults to time.RFC3339Nano
+*/
+type LogfmtEncoder struct {
+	TimeLayout string
+	Location   *time.Location
+}
+
+func (le LogfmtEncoder) Encode(entry Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	writeLogfmtPair(&buf, "time", formatTime(entry.Time, le.TimeLayout, time.RFC3339Nano, le.Location))
+	buf.WriteByte(' ')
+	writeLogfmtPair(&buf, "severity", entry.Severity.String())
+	buf.WriteByte(' ')
+	writeLogfmtPair(&buf, "message", entry.Message)
+	for _, f := range entry.Fields {
+		buf.WriteByte(' ')
+		writeLogfmtPair(&buf, f.Key, fmt.Sprint(f.Value))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func formatTime(t time.Time, layout, defaultLayout string, location *time.Location) string {
+	if layout == "" {
+		layout = defaultLayout
+	}
+	if location != nil {
+		t = t.In(location)
+	}
+	return t.Format(layout)
+}
+
+// writeJSON never fails: values that cannot be marshalled are written as their fmt representation
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	if err := encoder.Encode(value); err != nil {
+		encoded.Reset()
+		encoder.Encode(fmt.Sprint(value))
+	}
+	buf.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	if needsQuoting(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+// logfmtKey replaces the characters a key cannot hold with underscores
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func needsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == unicode.ReplacementChar || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeControl keeps printable text as it is and escapes the rest the way strconv.Quote does
+func escapeControl(message string) string {
+	var buf strings.Builder
+	for _, r := range message {
+		if r == unicode.ReplacementChar || unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			buf.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	entry := Entry{
+		Time:     time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC),
+		Severity: Warning,
+		Message:  "disk almost full\nreally",
+		Fields:   []Field{F("path", "/var/log"), F("free", 3), F("error", errors.New("no space"))},
+	}
+	tests := []struct {
+		name    string
+		encoder Encoder
+		want    string
+	}{
+		{"text", TextEncoder{Location: time.UTC},
+			"2026-10-18T08:30:00 - [WARNING]disk almost full\\nreally path=/var/log free=3 error=\"no space\"\n"},
+		{"json", JSONEncoder{Location: time.UTC},
+			`{"time":"2026-10-18T08:30:00Z","severity":"WARNING","message":"disk almost full\nreally","path":"/var/log","free":3,"error":"no space"}` + "\n"},
+		{"logfmt", LogfmtEncoder{Location: time.UTC},
+			`time=2026-10-18T08:30:00Z severity=WARNING message="disk almost full\nreally" path=/var/log free=3 error="no space"` + "\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line, err := test.encoder.Encode(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(line) != test.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", line, test.want)
+			}
+		})
+	}
+}
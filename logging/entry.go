@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Severity of a log entry. The values are ordered, so a logger can drop everything below a minimum severity
+*/
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+const numSeverities = 3
+
+var severityNames = [numSeverities]string{"INFO", "WARNING", "ERROR"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity accepts the names printed by String, case insensitive
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return Severity(i), nil
+		}
+	}
+	return Info, fmt.Errorf("logging: unknown severity %q", name)
+}
+
+// Field is a key/value pair attached to an entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F is a short constructor for a Field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Entry is the unit that travels through the logger channel to the sinks
+type Entry struct {
+	Time     time.Time
+	Severity Severity
+	Message  string
+	Fields   []Field
+}
@@ -0,0 +1,241 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config of a Logger. The zero value logs INFO and above to the console with a buffer of 50 entries
+type Config struct {
+	BufferSize  int
+	MinSeverity Severity
+	Sinks       []Sink
+	// Overflow is applied when the buffer is full. Defaults to Block
+	Overflow OverflowPolicy
+	// SampleEvery is used by the Sample policy. Defaults to 10
+	SampleEvery int
+	// OnError is called from the logger goroutine when a sink fails. Defaults to printing on os.Stderr
+	OnError func(err error)
+}
+
+/*
+Logger owns a buffered channel of entries and a single goroutine that hands them to the sinks.
+Callers never touch the sinks directly, so a slow sink does not need its own locking
+*/
+type Logger struct {
+	logged            [numSeverities]int64 //entries accepted by Log. Accessed atomically
+	droppedBySeverity [numSeverities]int64 //every entry lost, by overflow or by Close. Accessed atomically
+	dropped           int64                //entries lost because of Close. Accessed atomically
+	overflowCount     int64                //overflowing entries seen by the Sample policy. Accessed atomically
+	entries           chan Entry
+	done              chan struct{}
+	stopped           chan struct{}
+	mutex             sync.RWMutex //guards closed and the senders.Add calls, it is never held while sending
+	closed            bool
+	senders           sync.WaitGroup //Log calls in progress, the goroutine waits for them before draining
+	drainCtx          context.Context
+	sinks             []Sink
+	minSeverity       Severity
+	overflow          OverflowPolicy
+	sampleEvery       int64
+	onError           func(err error)
+}
+
+// New starts the logger goroutine. Call Close to flush the buffer and release it
+func New(config Config) *Logger {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 50
+	}
+	if config.SampleEvery <= 0 {
+		config.SampleEvery = 10
+	}
+	if len(config.Sinks) == 0 {
+		config.Sinks = []Sink{NewConsoleSink(os.Stdout, nil)}
+	}
+	if config.OnError == nil {
+		config.OnError = func(err error) {
+			fmt.Fprintln(os.Stderr, "logging:", err)
+		}
+	}
+	l := &Logger{
+		entries:     make(chan Entry, config.BufferSize),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		sinks:       config.Sinks,
+		minSeverity: config.MinSeverity,
+		overflow:    config.Overflow,
+		sampleEvery: int64(config.SampleEvery),
+		onError:     config.OnError,
+	}
+	go l.run()
+	return l
+}
+
+// Enabled reports whether entries of the given severity pass the filter
+func (l *Logger) Enabled(severity Severity) bool {
+	return severity >= l.minSeverity
+}
+
+// Log queues an entry. It is a no op for severities below the minimum or after Close
+func (l *Logger) Log(severity Severity, message string, fields ...Field) {
+	if !l.Enabled(severity) {
+		return
+	}
+	entry := Entry{Time: time.Now(), Severity: severity, Message: message, Fields: fields}
+	l.mutex.RLock()
+	if l.closed {
+		l.mutex.RUnlock()
+		return
+	}
+	l.senders.Add(1)
+	l.mutex.RUnlock()
+	defer l.senders.Done()
+	atomic.AddInt64(&l.logged[severityIndex(severity)], 1)
+	l.enqueue(entry)
+}
+
+func (l *Logger) Info(message string, fields ...Field) {
+	l.Log(Info, message, fields...)
+}
+
+func (l *Logger) Warning(message string, fields ...Field) {
+	l.Log(Warning, message, fields...)
+}
+
+func (l *Logger) Error(message string, fields ...Field) {
+	l.Log(Error, message, fields...)
+}
+
+/*
+Close stops accepting entries and waits until every entry already in the buffer has been written.
+If ctx ends first, Close returns ctx.Err() and the goroutine discards whatever is left instead of writing it.
+The first return value is the number of entries that were not written. When the deadline passed it is a
+snapshot taken at that moment and does not include an entry that a sink is still busy with.
+Close does not wait for Log calls blocked on a full buffer (Block policy): they give up and count their entry as dropped
+*/
+func (l *Logger) Close(ctx context.Context) (int, error) {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		select {
+		case <-l.stopped:
+			return 0, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	l.closed = true
+	l.drainCtx = ctx
+	l.mutex.Unlock()
+	close(l.done)
+
+	select {
+	case <-l.stopped:
+		return int(atomic.LoadInt64(&l.dropped)), nil
+	case <-ctx.Done():
+		return int(atomic.LoadInt64(&l.dropped)) + len(l.entries), ctx.Err()
+	}
+}
+
+/*
+select statement on an infinity loop. return (not break) is needed to leave the for loop.
+done is checked first: select picks at random when both are ready, and after Close only drain may write
+*/
+func (l *Logger) run() {
+	defer close(l.stopped)
+	defer l.closeSinks()
+	for {
+		select {
+		case <-l.done:
+			l.stop()
+			return
+		default:
+		}
+		select {
+		case entry := <-l.entries:
+			l.write(entry)
+		case <-l.done:
+			l.stop()
+			return
+		}
+	}
+}
+
+func (l *Logger) stop() {
+	l.senders.Wait() //they return quickly now that done is closed
+	l.drain()
+}
+
+// drain empties the buffer after Close. No sender is left, so an empty channel means we are done
+func (l *Logger) drain() {
+	for {
+		select {
+		case entry := <-l.entries:
+			if l.drainCtx.Err() != nil {
+				atomic.AddInt64(&l.dropped, 1)
+				l.countDrop(entry.Severity)
+				continue
+			}
+			l.write(entry)
+		default:
+			return
+		}
+	}
+}
+
+// Dropped returns how many entries of each severity were lost, either by the overflow policy or by Close
+func (l *Logger) Dropped() map[Severity]int64 {
+	result := make(map[Severity]int64, numSeverities)
+	for i := range l.droppedBySeverity {
+		result[Severity(i)] = atomic.LoadInt64(&l.droppedBySeverity[i])
+	}
+	return result
+}
+
+// Logged returns how many entries of each severity were accepted by Log, the dropped ones included
+func (l *Logger) Logged() map[Severity]int64 {
+	result := make(map[Severity]int64, numSeverities)
+	for i := range l.logged {
+		result[Severity(i)] = atomic.LoadInt64(&l.logged[i])
+	}
+	return result
+}
+
+func (l *Logger) countDrop(severity Severity) {
+	atomic.AddInt64(&l.droppedBySeverity[severityIndex(severity)], 1)
+}
+
+// severityIndex counts unknown severities as errors rather than losing track of them
+func severityIndex(severity Severity) int {
+	if severity < 0 || int(severity) >= numSeverities {
+		return int(Error)
+	}
+	return int(severity)
+}
+
+func (l *Logger) nextOverflow() int64 {
+	return atomic.AddInt64(&l.overflowCount, 1)
+}
+
+func (l *Logger) write(entry Entry) {
+	for _, sink := range l.sinks {
+		if err := sink.Write(entry); err != nil {
+			l.onError(err)
+		}
+	}
+}
+
+func (l *Logger) closeSinks() {
+	for _, sink := range l.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				l.onError(err)
+			}
+		}
+	}
+}
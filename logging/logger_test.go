@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stuckSink blocks every Write until release is closed
+type stuckSink struct {
+	release chan struct{}
+}
+
+func (s stuckSink) Write(entry Entry) error {
+	<-s.release
+	return nil
+}
+
+/*
+gateSink writes to a RingSink, but holds the first entry until release is closed. Once started is closed the
+logger goroutine is stuck in the sink, so the buffer fills and overflows exactly as the test logs
+*/
+type gateSink struct {
+	ring    *RingSink
+	started chan struct{}
+	release chan struct{}
+	first   bool
+}
+
+func newGateSink() *gateSink {
+	return &gateSink{ring: NewRingSink(100), started: make(chan struct{}), release: make(chan struct{}), first: true}
+}
+
+func (gs *gateSink) Write(entry Entry) error {
+	if gs.first {
+		gs.first = false
+		close(gs.started)
+		<-gs.release
+	}
+	return gs.ring.Write(entry)
+}
+
+func (gs *gateSink) messages() []string {
+	var result []string
+	for _, entry := range gs.ring.Entries() {
+		result = append(result, entry.Message)
+	}
+	return result
+}
+
+func TestCloseReturnsAtDeadlineWithStuckSink(t *testing.T) {
+	sink := stuckSink{release: make(chan struct{})}
+	defer close(sink.release)
+	logger := New(Config{BufferSize: 1, Sinks: []Sink{sink}, Overflow: Block})
+
+	// one entry stuck in the sink, one in the buffer and the others blocked in Log
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("blocked")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := logger.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close() took %v after a 50ms deadline", elapsed)
+	}
+
+	logged := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("Log calls are still blocked after Close")
+	}
+	if dropped := logger.Dropped()[Info]; dropped < 3 {
+		t.Errorf("Dropped()[Info] = %d, want at least the 3 blocked entries", dropped)
+	}
+}
+
+func TestCloseWritesBufferedEntries(t *testing.T) {
+	ring := NewRingSink(100)
+	logger := New(Config{BufferSize: 10, Sinks: []Sink{ring}})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Warning("entry")
+		}()
+	}
+	wg.Wait()
+	dropped, err := logger.Close(context.Background())
+	if err != nil || dropped != 0 {
+		t.Fatalf("Close() = %d, %v, want 0, nil", dropped, err)
+	}
+	if got := len(ring.Entries()); got != 50 {
+		t.Errorf("ring sink has %d entries, want 50", got)
+	}
+	logger.Info("after close") // no op, must not panic or block
+	if got := logger.Logged()[Warning]; got != 50 {
+		t.Errorf("Logged()[Warning] = %d, want 50", got)
+	}
+}
+
+func TestCloseAfterDeadlineDropsBufferedEntries(t *testing.T) {
+	sink := newGateSink()
+	logger := New(Config{BufferSize: 10, Sinks: []Sink{sink}})
+	logger.Info("held")
+	<-sink.started
+	for i := 0; i < 5; i++ {
+		logger.Error("buffered")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dropped, err := logger.Close(ctx)
+	if err != context.Canceled || dropped != 5 {
+		t.Errorf("Close() = %d, %v, want 5, %v", dropped, err, context.Canceled)
+	}
+	close(sink.release)
+	if _, err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if got := sink.messages(); !reflect.DeepEqual(got, []string{"held"}) {
+		t.Errorf("written %v, want only the entry that was in the sink", got)
+	}
+	if got := logger.Dropped()[Error]; got != 5 {
+		t.Errorf("Dropped()[Error] = %d, want 5", got)
+	}
+}
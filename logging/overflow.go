@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+/*
+OverflowPolicy decides what Log does when the buffered channel is full
+*/
+type OverflowPolicy int
+
+const (
+	// Block waits for the goroutine to make room. Nothing is lost before Close, but callers stall behind slow sinks
+	Block OverflowPolicy = iota
+	// DropNewest discards the entry being logged
+	DropNewest
+	// DropOldest discards the oldest buffered entry to make room for the new one
+	DropOldest
+	// Sample keeps one out of every SampleEvery overflowing entries (replacing the oldest) and drops the rest
+	Sample
+)
+
+var overflowPolicyNames = []string{"block", "drop-newest", "drop-oldest", "sample"}
+
+func (p OverflowPolicy) String() string {
+	if p < 0 || int(p) >= len(overflowPolicyNames) {
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+	return overflowPolicyNames[p]
+}
+
+/*
+enqueue applies the overflow policy. The caller is counted in senders, so the goroutine does not drain before
+it returns. A blocked send gives up when Close is called, otherwise a stuck sink would block Close too
+*/
+func (l *Logger) enqueue(entry Entry) {
+	if l.overflow == Block {
+		select {
+		case l.entries <- entry:
+			return
+		default:
+		}
+		select {
+		case l.entries <- entry:
+		case <-l.done:
+			atomic.AddInt64(&l.dropped, 1)
+			l.countDrop(entry.Severity)
+		}
+		return
+	}
+	select {
+	case l.entries <- entry:
+		return
+	default:
+	}
+	if l.overflow == DropNewest {
+		l.countDrop(entry.Severity)
+		return
+	}
+	if l.overflow == Sample && l.nextOverflow()%l.sampleEvery != 0 {
+		l.countDrop(entry.Severity)
+		return
+	}
+	for {
+		select {
+		case l.entries <- entry:
+			return
+		default:
+		}
+		//the goroutine may win the race for the oldest entry, then we simply try again
+		select {
+		case oldest := <-l.entries:
+			l.countDrop(oldest.Severity)
+		default:
+		}
+	}
+}
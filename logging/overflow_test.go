@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestOverflowPolicies(t *testing.T) {
+	// entry 0 is held by the sink, 1 and 2 fill the buffer of 2, the Warning entries 3 to 6 overflow
+	tests := []struct {
+		policy  OverflowPolicy
+		written []string
+		dropped map[Severity]int64
+	}{
+		{Block, []string{"0", "1", "2", "3", "4", "5", "6"}, map[Severity]int64{Info: 0, Warning: 0, Error: 0}},
+		{DropNewest, []string{"0", "1", "2"}, map[Severity]int64{Info: 0, Warning: 4, Error: 0}},
+		{DropOldest, []string{"0", "5", "6"}, map[Severity]int64{Info: 2, Warning: 2, Error: 0}},
+		// every second overflowing entry (4 and 6) replaces the oldest buffered one
+		{Sample, []string{"0", "4", "6"}, map[Severity]int64{Info: 2, Warning: 2, Error: 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			sink := newGateSink()
+			logger := New(Config{BufferSize: 2, Sinks: []Sink{sink}, Overflow: test.policy, SampleEvery: 2})
+			logger.Info("0")
+			<-sink.started
+			logger.Info("1")
+			logger.Info("2")
+			overflowed := make(chan struct{})
+			go func() {
+				defer close(overflowed)
+				for i := 3; i <= 6; i++ {
+					logger.Warning(strconv.Itoa(i))
+				}
+			}()
+			if test.policy != Block {
+				<-overflowed // nothing waits for room, so the overflow is complete before the sink is released
+			}
+			close(sink.release)
+			<-overflowed
+			if dropped, err := logger.Close(context.Background()); err != nil || dropped != 0 {
+				t.Fatalf("Close() = %d, %v, want 0, nil", dropped, err)
+			}
+			if got := sink.messages(); !reflect.DeepEqual(got, test.written) {
+				t.Errorf("written %v, want %v", got, test.written)
+			}
+			if got := logger.Dropped(); !reflect.DeepEqual(got, test.dropped) {
+				t.Errorf("Dropped() = %v, want %v", got, test.dropped)
+			}
+			if got := logger.Logged(); got[Info] != 3 || got[Warning] != 4 {
+				t.Errorf("Logged() = %v, want 3 Info and 4 Warning", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import "firstApp/writers"
+
+/*
+RotatingFileSink appends encoded entries to a writers.RotatingFile. When the file would grow over MaxBytes it is
+renamed to path.1, path.1 becomes path.2 and so on. Only MaxBackups old files are kept
+*/
+type RotatingFileSink struct {
+	file    *writers.RotatingFile
+	encoder Encoder
+}
+
+// NewRotatingFileSink opens (or creates) path for appending. A nil encoder means TextEncoder{}
+func NewRotatingFileSink(path string, maxBytes int64, maxBackups int, encoder Encoder) (*RotatingFileSink, error) {
+	return NewRotatingFileSinkWithConfig(writers.RotatingFileConfig{Path: path, MaxBytes: maxBytes, MaxBackups: maxBackups}, encoder)
+}
+
+// NewRotatingFileSinkWithConfig is NewRotatingFileSink with every option of writers.RotatingFile, the age limit included
+func NewRotatingFileSinkWithConfig(config writers.RotatingFileConfig, encoder Encoder) (*RotatingFileSink, error) {
+	if encoder == nil {
+		encoder = TextEncoder{}
+	}
+	file, err := writers.NewRotatingFile(config)
+	if err != nil {
+		return nil, err
+	}
+	return &RotatingFileSink{file: file, encoder: encoder}, nil
+}
+
+func (rs *RotatingFileSink) Write(entry Entry) error {
+	line, err := rs.encoder.Encode(entry)
+	if err != nil {
+		return err
+	}
+	_, err = rs.file.Write(line)
+	return err
+}
+
+func (rs *RotatingFileSink) Close() error {
+	return rs.file.Close()
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSinkKeepsWritingWhenRotationFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	// a non empty directory where the backup should go makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err := NewRotatingFileSink(path, 10, 1, TextEncoder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	entry := Entry{Time: time.Now(), Severity: Info, Message: "first entry, longer than the limit"}
+	if err := sink.Write(entry); err != nil {
+		t.Fatalf("first Write() error = %v", err)
+	}
+	entry.Message = "second entry"
+	if err := sink.Write(entry); err == nil {
+		t.Error("Write() did not report the failed rotation")
+	}
+	entry.Message = "third entry"
+	if err := sink.Write(entry); err != nil {
+		t.Errorf("Write() retried the rotation right away: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"first entry", "second entry", "third entry"} {
+		if !strings.Contains(string(data), message) {
+			t.Errorf("%q is missing from the log file:\n%s", message, data)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+/*
+Sink receives every entry that passes the severity filter. Sinks are called from the logger goroutine only,
+one entry at a time. If a sink also implements io.Closer it is closed when the logger stops
+*/
+type Sink interface {
+	Write(entry Entry) error
+}
+
+// ConsoleSink writes encoded entries to an io.Writer, the terminal by default
+type ConsoleSink struct {
+	out     io.Writer
+	encoder Encoder
+}
+
+// NewConsoleSink writes to out, or to os.Stdout when out is nil. A nil encoder means TextEncoder{}
+func NewConsoleSink(out io.Writer, encoder Encoder) *ConsoleSink {
+	if out == nil {
+		out = os.Stdout
+	}
+	if encoder == nil {
+		encoder = TextEncoder{}
+	}
+	return &ConsoleSink{out: out, encoder: encoder}
+}
+
+func (cs *ConsoleSink) Write(entry Entry) error {
+	line, err := cs.encoder.Encode(entry)
+	if err != nil {
+		return err
+	}
+	_, err = cs.out.Write(line)
+	return err
+}
+
+/*
+RingSink keeps the last N entries in memory. Useful in tests to assert what has been logged
+*/
+type RingSink struct {
+	mutex   sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRingSink keeps up to size entries. A size below 1 is treated as 1
+func NewRingSink(size int) *RingSink {
+	if size < 1 {
+		size = 1
+	}
+	return &RingSink{entries: make([]Entry, size)}
+}
+
+func (rs *RingSink) Write(entry Entry) error {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.entries[rs.next] = entry
+	rs.next = (rs.next + 1) % len(rs.entries)
+	if rs.next == 0 {
+		rs.full = true
+	}
+	return nil
+}
+
+// Entries returns a copy of the stored entries, oldest first
+func (rs *RingSink) Entries() []Entry {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	if !rs.full {
+		return append([]Entry(nil), rs.entries[:rs.next]...)
+	}
+	result := make([]Entry, 0, len(rs.entries))
+	result = append(result, rs.entries[rs.next:]...)
+	return append(result, rs.entries[:rs.next]...)
+}
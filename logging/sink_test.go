@@ -0,0 +1,17 @@
+package logging
+
+import "testing"
+
+func TestRingSinkKeepsTheLastEntries(t *testing.T) {
+	ring := NewRingSink(3)
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		ring.Write(Entry{Message: message})
+	}
+	var got []string
+	for _, entry := range ring.Entries() {
+		got = append(got, entry.Message)
+	}
+	if len(got) != 3 || got[0] != "3" || got[2] != "5" {
+		t.Errorf("Entries() = %v, want [3 4 5]", got)
+	}
+}
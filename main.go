@@ -1,15 +1,30 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt" //	Package fmt implements formatted I/O with functions analogous to C's printf and scanf.
-	"io/ioutil"
-	"log"
-	"net/http"
+	"math"
+	"os"
 	"reflect"
 	"runtime"
 	"strconv" //Package strconv implements conversions to and from string representations of basic data types.
 	"sync"
 	"time"
+
+	"firstApp/arith"
+	"firstApp/auth"
+	"firstApp/counter"
+	"firstApp/countries"
+	"firstApp/doctors"
+	"firstApp/logging"
+	"firstApp/metrics"
+	"firstApp/population"
+	"firstApp/stats"
+	"firstApp/validation"
+	"firstApp/writers"
 )
 
 //Declare variable on package level. Have to use full declaration syntax
@@ -34,51 +49,58 @@ const (
 	snakeSpecialist
 )
 
-const (
-	isAdmin = 1 << iota
-	isHeadquarters
-	canSeeAfrica
-	canSeeAsia
-	canSeeEurope
-	canSeeNorthAmerica
-	canSeeSouthAmerica
-)
-
 /*You need to use capital letters to all variables of the struct to be visible outside of the package !!!!!
 No underscores on field names or struct names*/
-type Doctor struct {
-	Number     int
-	ActorName  string
-	Companions []string
-}
+type Doctor = doctors.Doctor //the struct lives in the doctors package, which serves it over HTTP at /doctors
 
 /*
-Tags to make some validations on the data `required max:"100"`
+Tags to make some validations on the data. Tags are `key:"value"` pairs, the validation package reads the validate key
 */
 type Animal struct {
-	Name   string `required max : "100"`
-	Origin string
+	Name   string `validate:"required,max=100"`
+	Origin string `validate:"omitempty,max=100"`
 }
 
 type Bird struct {
-	Animal   //composition or embedding
-	SpeedKPH float32
+	Animal           //composition or embedding. Validation walks into it and reports paths like Animal.Name
+	SpeedKPH float32 `validate:"min=0"`
 	CanFly   bool
 }
 
+/*
+A rule that needs more than one field is registered for the whole struct
+*/
+func init() {
+	validation.RegisterStruct(Bird{}, func(v interface{}) []*validation.FieldError {
+		bird := v.(Bird)
+		if bird.CanFly && bird.SpeedKPH <= 0 {
+			return []*validation.FieldError{{Field: "SpeedKPH", Code: "flyingspeed", Value: bird.SpeedKPH}}
+		}
+		return nil
+	})
+	validation.AddMessages(validation.English, validation.Catalog{"flyingspeed": "{field} must be above 0 for a bird that can fly"})
+	validation.AddMessages(validation.Greek, validation.Catalog{"flyingspeed": "Το πεδίο {field} πρέπει να είναι πάνω από 0 για πουλί που πετάει"})
+}
+
 /*
 Synchronize multiple GoRoutines together
 */
 var wg = sync.WaitGroup{}
-var counter = 0
 
 /*
-Many things can read the data, but only one can write
-When a writer arrives, it waits for all the readers to finish
+Shared by the sayHello2 and increment goroutines. It used to be an int guarded by a sync.RWMutex
+(many things can read the data, but only one can write), the atomic counter needs no locks
 */
-var mutex = sync.RWMutex{}
+var helloCounter counter.Atomic
+
+// registry collects the counts of the examples, cmd/server serves its own at /metrics
+var registry = metrics.NewRegistry()
+
+// liveCountries makes runResourceRequest ask the real service instead of replaying the recorded responses
+var liveCountries = flag.Bool("live", false, "query restcountries.com instead of the recorded fixtures")
 
 func main() {
+	flag.Parse()
 	/*
 		n Go, := is for declaration + assignment, whereas = is for assignment only.
 		For example, var foo int = 10 is the same as foo := 10
@@ -100,7 +122,7 @@ func main() {
 
 	//prints an the unicode representation of 42 which is an asterisk
 	var changeVariableType string
-	changeVariableType = string(a)
+	changeVariableType = string(rune(a))
 	fmt.Printf("%v, %T \n", changeVariableType, changeVariableType)
 	changeVariableType = strconv.Itoa(a)
 	fmt.Printf("%v, %T \n", changeVariableType, changeVariableType)
@@ -127,11 +149,19 @@ func main() {
 	var specialistType int = 2
 	fmt.Printf("%v, %T \n", specialistType == snakeSpecialist, specialistType == snakeSpecialist)
 
-	//iota as a switch statement
-	var roles byte = isAdmin | canSeeNorthAmerica | canSeeSouthAmerica
-	fmt.Printf("%b, %T \n", roles, roles)
-	fmt.Printf("Is Admin? %v \n", isAdmin&roles == isAdmin)
-	fmt.Printf("Is canSeeEurope? %v \n", canSeeEurope&roles == canSeeEurope)
+	//iota as a switch statement. The 1 << iota bit flags live in the auth package as the Roles type
+	var roles auth.Roles = auth.IsAdmin | auth.CanSeeNorthAmerica | auth.CanSeeSouthAmerica
+	fmt.Printf("%b, %T, %v \n", byte(roles), roles, roles)
+	fmt.Printf("Is Admin? %v \n", roles.Has(auth.IsAdmin))
+	fmt.Printf("Is canSeeEurope? %v \n", roles.CanSee(auth.Europe))
+	roles.Grant(auth.CanSeeEurope)
+	rolesJSON, _ := json.Marshal(roles)
+	fmt.Printf("Roles as JSON: %s \n", rolesJSON)
+	//a byte holds 8 flags only. RoleSet grows as needed and keeps the bit positions of Roles
+	roleSet := roles.Set()
+	roleSet.Grant(auth.SeeOceania, auth.SeeAntarctica)
+	fmt.Printf("Role set: %v, can see Antarctica? %v \n", roleSet, roleSet.CanSee(auth.Antarctica))
+	policyExample(roleSet)
 
 	//   ARRAYS AND SLICES (two collection types)
 	grades := [3]int{97, 85, 93} //or
@@ -214,6 +244,23 @@ func main() {
 	delete(sp, "Ohio")
 	fmt.Printf("size %v\n", len(sp))
 
+	//population.Store copies the map and guards it with a RWMutex, so goroutines can share it
+	store := population.NewStore(statePopulations)
+	store.Set("Ohio", 11632432)
+	if pop, ok := store.Get("Ohio"); ok {
+		fmt.Printf("Ohio population in store: %v, in map: %v \n", pop, statePopulations["Ohio"])
+	}
+	fmt.Printf("Total: %v, top 2: %v \n", store.Total(), store.TopN(2))
+
+	//a checkpoint is frozen: the store copies its map on the next write instead of changing the checkpoint
+	before := store.Checkpoint()
+	store.DeleteBy(actorName, "Ohio")
+	after := store.Checkpoint()
+	ohioBefore, _ := before.Get("Ohio")
+	_, ohioAfter := after.Get("Ohio")
+	fmt.Printf("Ohio in checkpoint %v: %v, still there in checkpoint %v? %v \n", before.ID, ohioBefore, after.ID, ohioAfter)
+	fmt.Printf("Diff: %+v, changes: %+v \n", population.Diff(before, after), store.Changes(0))
+
 	//		STRUCT
 	aDoctor := Doctor{
 		Number:    3,
@@ -262,7 +309,17 @@ func main() {
 	//validation library should read tag via reflection
 	t := reflect.TypeOf(Animal{})
 	field, _ := t.FieldByName("Name")
-	fmt.Println(field.Tag)
+	fmt.Println(field.Tag, field.Tag.Get("validate"))
+	fmt.Println(validation.Validate(birdInstance2))                                        //<nil>
+	fmt.Println(validation.Validate(Bird{Animal: Animal{Origin: "Greece"}, SpeedKPH: -1})) //every failing field
+	fmt.Println(validation.Validate(Bird{Animal: Animal{Name: "Kiwi"}, CanFly: true}))     //struct level rule
+	if err := validation.Validate(Doctor{Number: 3, ActorName: "John", Companions: []string{"Mike", "", "Mike"}}); err != nil {
+		var validationErrors validation.Errors
+		if errors.As(err, &validationErrors) { //what an HTTP handler does to answer with 422
+			report, _ := json.Marshal(validationErrors.Localize(validation.Greek))
+			fmt.Printf("%v %s \n", validationErrors.StatusCode(), report)
+		}
+	}
 
 	//   IF AND SWITCH STATEMENTS
 	if pop, ok := statePopulations["Florida"]; ok {
@@ -407,11 +464,34 @@ func main() {
 	//divWithPanicResult := divideWithPanic(5, 0) //rThe program will stop
 	fmt.Println(divResult)
 	divResult2Types, err := divideWithTwoReturnTypes(5, 0)
-	if err != nil {
+	if errors.Is(err, arith.ErrDivideByZero) {
 		fmt.Println(err)
 	}
 	fmt.Println(divResult2Types)
 
+	//checked integer arithmetic. The wrapped result is returned together with the error
+	if _, err := arith.AddInt8(100, 100); errors.Is(err, arith.ErrOverflow) {
+		fmt.Println(err)
+	}
+	wrapped, _ := arith.AddInt8(100, 100)
+	fmt.Println("int8 100 + 100 wraps to", wrapped)
+
+	//exact arithmetic for populations and money. Sums are never wrong and divisions round the way we ask
+	fmt.Println("The exact sum is", arith.SumBig(math.MaxInt64, math.MaxInt64))
+	if _, err := arith.SumInts(math.MaxInt64, 1); err != nil {
+		fmt.Println(err)
+	}
+	tenth, fifth := 0.1, 0.2 //variables, constants are added exactly by the compiler
+	floatSum, _ := arith.DefaultContext.SumFloat64(tenth, fifth)
+	fmt.Println("0.1 + 0.2 =", tenth+fifth, "in decimal", floatSum)
+	share, _ := arith.Money.DivideInts(10, 3)
+	fmt.Println("10 / 3 rounded to cents is", share)
+	precise := arith.Context{Precision: 30, Rounding: arith.Down}
+	oneSeventh, _ := precise.DivideInts(1, 7)
+	fmt.Println("1 / 7 with 30 digits is", oneSeventh)
+
+	statisticsExample()
+
 	//in GO functions can be passed as parameters in functions
 	//anonymous function.
 	func() {
@@ -435,7 +515,16 @@ func main() {
 
 	// INTERFACES
 	var w Writer = ConsoleWriter{} //polymorphic behaviour
-	w.Write([]byte("Hello Go for interface !!!"))
+	w.Write([]byte("Hello Go for interface !!!\n"))
+
+	//the same interface, other implementations: the buffered writer holds the data until Flush
+	//and the multi writer sends it both to the console and to memory
+	var memory writers.Memory
+	buffered := writers.NewBuffered(writers.NewMulti(ConsoleWriter{}, &memory), 0)
+	buffered.Write([]byte("Hello Go from the buffered writer\n"))
+	fmt.Printf("Written to memory before Flush: %q\n", memory.String())
+	buffered.Flush()
+	fmt.Printf("Written to memory after Flush: %q\n", memory.String())
 
 	/*
 		Interfaces can work the other way around. For example we have an existing concrete implementation
@@ -464,18 +553,20 @@ func main() {
 	wg.Wait()
 	//time.Sleep(100 * time.Millisecond)		//you should use weightGroup instead of time.Sleep
 
-	//we should use mutex to synchronize the increments
-	//The locks must be executed before each goroutine call, else the same data will be printed on sayHello2
-	//lock in the same context. Here, you should remove the concurrency. Run on a single thread, because you have the overhead
-	//of locking and unlocking the mutexes
+	//the counter is safe across goroutines, but nothing orders the goroutines: the same value can be printed twice
+	//use counter.Mutex when more state has to change under the same lock, counter.Striped for very hot counters
+	//and go test -bench . -cpu 1,4,16 ./counter to compare them
 	for i := 0; i < 10; i++ {
 		wg.Add(2)
-		mutex.RLock() //read lock
 		go sayHello2()
-		mutex.Lock()
 		go increment()
 	}
 	wg.Wait()
+	fmt.Println("Counted", helloCounter.Value())
+	registry.CounterFunc("hello_increments_total", "Increments of the hello counter.", nil, func(emit metrics.Emit) {
+		emit(float64(helloCounter.Value()))
+	})
+	registry.WriteText(os.Stdout) //Prometheus text format
 
 	//number of threads available in the application
 	//number of operating system threads equal to the number of cores.
@@ -504,50 +595,54 @@ func main() {
 }
 
 const (
-	logInfo    = "INFO"
-	logWarning = "WARNING"
-	logError   = "ERROR"
+	logInfo    = logging.Info
+	logWarning = logging.Warning
+	logError   = logging.Error
 )
 
-type logEntry struct {
-	time     time.Time
-	severity string
-	message  string
-}
-
-var logChannel = make(chan logEntry, 50)
-var doneChannel = make(chan struct{}) //saves memory allocation
-
+/*
+The logger used to be a package level logChannel plus a logger() goroutine with a doneChannel to stop it.
+It now lives in the logging package: every Logger owns its channel and goroutine and hands entries to sinks
+*/
 func loggerImplementation() {
-	go logger()
-	/*
-		You need to have a way to close the logChannel. You can use defer
-			defer func() {
-				close(logChannel)
-			}()
-		An other approach is to use a select statement. You must have a second channel to send a message when you need
-		to stop the application (doneChannel)
-	*/
+	ring := logging.NewRingSink(10) //keeps the last entries in memory, handy for tests
+	logger := logging.New(logging.Config{
+		BufferSize:  50,
+		MinSeverity: logInfo,
+		Sinks: []logging.Sink{
+			logging.NewConsoleSink(os.Stdout, logging.TextEncoder{}),
+			logging.NewConsoleSink(os.Stdout, logging.JSONEncoder{Location: time.UTC}), //what the log shipper reads
+			ring,
+		},
+	})
 
-	logChannel <- logEntry{time.Now(), logInfo, "App is starting"}
-	logChannel <- logEntry{time.Now(), logInfo, "App is shutting down"}
-	time.Sleep(100 * time.Millisecond)
-	doneChannel <- struct{}{}
+	logger.Info("App is starting", logging.F("season", season))
+	logger.Warning("Population data is compiled in", logging.F("states", 4))
+	logger.Info("App is shutting down")
+
+	//no time.Sleep needed: Close returns once the buffered entries are written or the deadline passes
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if dropped, err := logger.Close(ctx); err != nil {
+		fmt.Printf("Logger closed with %v dropped entries: %v \n", dropped, err)
+	}
+	fmt.Printf("Entries kept in memory: %v \n", len(ring.Entries()))
 }
 
-func logger() {
-	/*for entry := range logChannel {
-		fmt.Printf("%v - [%v]%v \n", entry.time.Format("2006-01-02T15:04:05"), entry.severity, entry.message)
-	}*/
-	//select statement on an infinity loop
-	for {
-		select {
-		case entry := <-logChannel:
-			fmt.Printf("%v - [%v]%v \n", entry.time.Format("2006-01-02T15:04:05"), entry.severity, entry.message)
-		case <-doneChannel:
-			break
-		}
+/*
+Authorize actions on top of the roles. Deny rules override allow rules and every decision ends up in the log
+*/
+func policyExample(roles auth.RoleSet) {
+	audit := logging.New(logging.Config{Sinks: []logging.Sink{logging.NewConsoleSink(os.Stdout, logging.LogfmtEncoder{})}})
+	policy := auth.NewPolicy(audit, auth.RegionRules("view")...)
+	policy.Add(auth.Rule{Name: "headquarters only edit", Action: "edit", Resource: "population:*", Effect: auth.Allow, Roles: auth.NewRoleSet(auth.Headquarters)})
+	policy.Add(auth.Rule{Name: "no south america for admins", Action: "*", Resource: "population:South America", Effect: auth.Deny, Roles: auth.NewRoleSet(auth.Admin)})
+
+	for _, continent := range []auth.Continent{auth.Europe, auth.Asia, auth.SouthAmerica} {
+		decision := policy.Evaluate(auth.Request{Subject: actorName, Roles: roles, Action: "view", Resource: auth.PopulationResource(continent)})
+		fmt.Printf("View %v? %v (%v) \n", continent, decision.Allowed, decision.Reason)
 	}
+	audit.Close(context.Background())
 }
 
 func goRoutineWithDifferentNumOfMessages() {
@@ -694,14 +789,12 @@ func channelExample1() {
 }
 
 func sayHello2() {
-	fmt.Printf("Hello # %v \n", counter)
-	mutex.RUnlock()
+	fmt.Printf("Hello # %v \n", helloCounter.Value())
 	wg.Done()
 }
 
 func increment() {
-	counter++
-	mutex.Unlock()
+	helloCounter.Increment()
 	wg.Done()
 }
 
@@ -727,10 +820,10 @@ func sayHello(message string) {
 	fmt.Println(message)
 }
 
-type Incrementer interface {
-	Increment() int
-}
+// Incrementer moved to the counter package, with implementations that are safe across goroutines
+type Incrementer = counter.Incrementer
 
+// IntCounter is not safe across goroutines, *ic++ is a read and a write. See counter.Atomic
 type IntCounter int
 
 func (ic *IntCounter) Increment() int {
@@ -751,9 +844,12 @@ You can add a struct with the signature of the interface as a method
 */
 type ConsoleWriter struct{}
 
+/*
+Used to be fmt.Println(string(data)), which added a newline and returned its length too. A Writer must write
+data as it is and return how many of its bytes were written. The writers package has more implementations
+*/
 func (cw ConsoleWriter) Write(data []byte) (int, error) {
-	n, err := fmt.Println(string(data))
-	return n, err
+	return writers.Console{}.Write(data)
 }
 
 type greeter struct {
@@ -770,24 +866,66 @@ func (g1 greeter) greet() {
 }
 
 func divideWithTwoReturnTypes(a, b float64) (float64, error) {
-	if b == 0.0 {
-		return 0.0, fmt.Errorf("cannot divide by zero")
+	result, err := arith.ReturnError.Divide(a, b)
+	if err != nil {
+		return 0.0, err
 	}
-	return a / b, nil
+	return result, nil
 }
 
 func divide(a, b float64) float64 {
-	return a / b
+	result, _ := arith.IEEE.Divide(a, b)
+	return result
 }
 
 /*
 The application will stop
 */
 func divideWithPanic(a, b float64) float64 {
-	if b == 0.0 {
-		panic("Cannot provide zero as second value")
+	result, _ := arith.Panic.Divide(a, b)
+	return result
+}
+
+/*
+Every worker aggregates the values it receives from its own channel and the partial results are merged at the end,
+the workers never wait for each other
+*/
+func statisticsExample() {
+	const workers = 4
+	partials := make([]*stats.Aggregator, workers)
+	channels := make([]chan float64, workers)
+	var wg sync.WaitGroup
+	for i := range partials {
+		partials[i] = stats.New(stats.Config{})
+		channels[i] = make(chan float64)
+		wg.Add(1)
+		go func(aggregator *stats.Aggregator, values <-chan float64) {
+			defer wg.Done()
+			aggregator.Consume(context.Background(), values)
+		}(partials[i], channels[i])
+	}
+	//every worker publishes how many values it has aggregated, read when the registry is written.
+	//cmd/server runs the same kind of workers over the request latencies and serves their counts at /metrics
+	registry.CounterFunc("stats_worker_values_total", "Values aggregated by each statistics worker.", []string{"worker"}, func(emit metrics.Emit) {
+		for i, partial := range partials {
+			emit(float64(partial.Count()), strconv.Itoa(i))
+		}
+	})
+	for v := 1; v <= 1000; v++ {
+		channels[v%workers] <- float64(v)
+	}
+	for _, values := range channels {
+		close(values)
 	}
-	return a / b
+	wg.Wait()
+
+	total := stats.New(stats.Config{})
+	for _, partial := range partials {
+		total.Merge(partial)
+	}
+	summary := total.Summary()
+	fmt.Printf("count %d sum %v min %v max %v mean %v variance %v p50 %.0f p99 %.0f\n",
+		summary.Count, summary.Sum, summary.Min, summary.Max, summary.Mean, summary.Variance, summary.P50, summary.P99)
 }
 
 func getSumPointer(values ...int) *int { //slice
@@ -800,13 +938,15 @@ func getSumPointer(values ...int) *int { //slice
 	return &result //in GO, this variable is promoted to be on the share memory (heap memory). The memory is not cleared
 }
 
+/*
+Only returns the total, the caller prints it. For more than the total (min, max, mean, percentiles...)
+use a stats.Aggregator, see statisticsExample
+*/
 func getSum(values ...int) int { //slice
-	fmt.Println(values)
 	result := 0
 	for _, v := range values {
 		result += v
 	}
-	fmt.Println("The sum is", result)
 	return result
 }
 
@@ -939,24 +1079,33 @@ func panicker() {
 		}
 	}()
 	panic("something bad happened")
-	fmt.Println("done panicking")
+	//fmt.Println("done panicking") //unreachable, the function stops at panic
 }
 
 /*
 Resource request from http package. With defer you can associate the opening and closing of a resource the one
-next to the other
+next to the other. The countries client does the request (and closes the body with defer) and returns an error
+instead of stopping the whole program with log.Fatal
 */
 func runResourceRequest() {
-	res, err := http.Get("https://restcountries.eu/rest/v2/name/greece")
-	if err != nil {
-		log.Fatal(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	//the recorded responses are replayed, so the demo runs offline (from the repository root). -live asks the real
+	//service, to record the fixtures again use countries.Record with the same DiskStore
+	transport := countries.NewCachingTransport(countries.NewDiskStore("countries/testdata/fixtures"), countries.Replay, nil)
+	if *liveCountries {
+		transport = countries.NewCachingTransport(countries.NewMemoryStore(), countries.Cache, nil)
 	}
-	defer res.Body.Close()
-	robots, err := ioutil.ReadAll(res.Body)
+	client := countries.NewClient(countries.Config{HTTPClient: transport.Client()})
+	result, err := client.GetByName(ctx, "greece")
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println("Country request failed:", err)
+		return
+	}
+	for _, country := range result {
+		fmt.Printf("%v (%v): capital %v, population %v \n", country.Name.Common, country.CCA3, country.Capital, country.Population)
 	}
-	fmt.Printf("%s", robots)
 }
 
 func returnTrue() bool {
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultBuckets suit request latencies in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// atomicFloat is a float64 stored as its bits, updated with compare and swap
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		if atomic.CompareAndSwapUint64(&f.bits, old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(value float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(value))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+type CounterVec struct {
+	f *family
+}
+
+// With returns the counter of the label values, in the order the labels were registered
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return v.f.with(labelValues, func() writer { return &Counter{} }).(*Counter)
+}
+
+// Counter only goes up. It restarts from zero with the process, Prometheus takes care of that
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add panics when delta is negative, use a Gauge for values that go down
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter cannot decrease by %v", delta))
+	}
+	c.value.add(delta)
+}
+
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+func (c *Counter) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, c.Value())
+}
+
+type GaugeVec struct {
+	f *family
+}
+
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return v.f.with(labelValues, func() writer { return &Gauge{} }).(*Gauge)
+}
+
+// Gauge is a value that goes up and down, like the requests in flight
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(value float64) {
+	g.value.set(value)
+}
+
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+func (g *Gauge) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, g.Value())
+}
+
+type HistogramVec struct {
+	f *family
+}
+
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	buckets := v.f.buckets
+	return v.f.with(labelValues, func() writer {
+		return &Histogram{upperBounds: buckets, counts: make([]uint64, len(buckets)+1)}
+	}).(*Histogram)
+}
+
+/*
+Histogram counts observations per bucket. Buckets are written cumulative, as Prometheus expects,
+and the count is the sum of the buckets so the two always agree
+*/
+type Histogram struct {
+	upperBounds []float64
+	counts      []uint64 // one more than upperBounds, for +Inf. Accessed atomically
+	sum         atomicFloat
+}
+
+func (h *Histogram) Observe(value float64) {
+	// the first bucket whose upper bound is >= value, bounds are inclusive ("le")
+	i := sort.SearchFloat64s(h.upperBounds, value)
+	atomic.AddUint64(&h.counts[i], 1)
+	h.sum.add(value)
+}
+
+// Count is the number of observations
+func (h *Histogram) Count() uint64 {
+	var count uint64
+	for i := range h.counts {
+		count += atomic.LoadUint64(&h.counts[i])
+	}
+	return count
+}
+
+func (h *Histogram) Sum() float64 {
+	return h.sum.load()
+}
+
+func (h *Histogram) write(w io.Writer, name, labels string) {
+	separator := ""
+	if labels != "" {
+		separator = ","
+	}
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		bound := math.Inf(1)
+		if i < len(h.upperBounds) {
+			bound = h.upperBounds[i]
+		}
+		writeSample(w, name+"_bucket", labels+separator+`le="`+formatFloat(bound)+`"`, float64(cumulative))
+	}
+	writeSample(w, name+"_sum", labels, h.Sum())
+	writeSample(w, name+"_count", labels, float64(cumulative))
+}
+
+// sample is the value emitted by a func metric
+type sample float64
+
+func (s sample) write(w io.Writer, name, labels string) {
+	writeSample(w, name, labels, float64(s))
+}
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the Prometheus type of a metric
+type Kind string
+
+const (
+	CounterKind   Kind = "counter"
+	GaugeKind     Kind = "gauge"
+	HistogramKind Kind = "histogram"
+)
+
+// ContentType of the Prometheus text format, version 0.0.4
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	namePattern  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Emit reports one value of a func metric, with as many label values as the metric has labels
+type Emit func(value float64, labelValues ...string)
+
+/*
+Registry holds named metric families. A family has a name, a help text and a fixed list of label names,
+every combination of label values is a separate child created on first use. Asking again for a family with the
+same name, kind and labels returns the existing one, so packages can share metrics without passing them around.
+A conflicting definition is a programming error and panics, like auth.NewPermission
+*/
+type Registry struct {
+	mutex    sync.RWMutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is the part common to every kind. children are keyed by their joined label values
+type family struct {
+	name     string
+	help     string
+	kind     Kind
+	labels   []string
+	buckets  []float64
+	collect  func(Emit)
+	mutex    sync.RWMutex
+	children map[string]*child
+}
+
+type child struct {
+	labelValues []string
+	metric      writer
+}
+
+// writer writes the samples of one child, labels is already formatted without the braces
+type writer interface {
+	write(w io.Writer, name, labels string)
+}
+
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, CounterKind, labels, nil, nil)}
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, GaugeKind, labels, nil, nil)}
+}
+
+// Histogram counts observations in buckets with the given upper bounds, nil means DefaultBuckets
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{r.register(name, help, HistogramKind, labels, buckets, nil)}
+}
+
+/*
+CounterFunc publishes a value kept somewhere else, like a counter.Counter or the counts of the logger.
+collect is called on every scrape and emits one value per combination of label values. Registering the name
+again replaces collect, so the values always come from the last caller
+*/
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func(Emit)) {
+	r.register(name, help, CounterKind, labels, nil, collect)
+}
+
+// GaugeFunc is CounterFunc for values that go up and down
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func(Emit)) {
+	r.register(name, help, GaugeKind, labels, nil, collect)
+}
+
+func (r *Registry) register(name, help string, kind Kind, labels []string, buckets []float64, collect func(Emit)) *family {
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !labelPattern.MatchString(label) || strings.HasPrefix(label, "__") || (kind == HistogramKind && label == "le") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %v", label, name))
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if existing, ok := r.families[name]; ok {
+		if existing.kind != kind || !equalStrings(existing.labels, labels) || !equalFloats(existing.buckets, buckets) ||
+			(existing.collect == nil) != (collect == nil) {
+			panic(fmt.Sprintf("metrics: %v is already registered with a different definition (%v)", name, existing.kind))
+		}
+		if collect != nil {
+			existing.mutex.Lock()
+			existing.collect = collect
+			existing.mutex.Unlock()
+		}
+		return existing
+	}
+	f := &family{
+		name:     name,
+		help:     help,
+		kind:     kind,
+		labels:   append([]string(nil), labels...),
+		buckets:  buckets,
+		collect:  collect,
+		children: make(map[string]*child),
+	}
+	r.families[name] = f
+	return f
+}
+
+// with returns the child of the label values, creating it with create the first time
+func (f *family) with(labelValues []string, create func() writer) writer {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %v expects %d label values %v, got %d", f.name, len(f.labels), f.labels, len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	f.mutex.RLock()
+	c, ok := f.children[key]
+	f.mutex.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if c, ok := f.children[key]; ok {
+		return c.metric
+	}
+	c = &child{labelValues: append([]string(nil), labelValues...), metric: create()}
+	f.children[key] = c
+	return c.metric
+}
+
+// WriteText writes every family in the Prometheus text format, sorted by name and label values
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mutex.RUnlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var buffer bytes.Buffer
+	for _, f := range families {
+		f.writeText(&buffer)
+	}
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+func (f *family) writeText(w *bytes.Buffer) {
+	children := f.snapshot()
+	if len(children) == 0 {
+		return
+	}
+	if f.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	for _, c := range children {
+		c.metric.write(w, f.name, formatLabels(f.labels, c.labelValues))
+	}
+}
+
+// snapshot returns the children sorted by label values. Func families are collected now
+func (f *family) snapshot() []*child {
+	var children []*child
+	f.mutex.RLock()
+	collect := f.collect
+	f.mutex.RUnlock()
+	if collect != nil {
+		collect(func(value float64, labelValues ...string) {
+			if len(labelValues) != len(f.labels) {
+				panic(fmt.Sprintf("metrics: %v expects %d label values %v, got %d", f.name, len(f.labels), f.labels, len(labelValues)))
+			}
+			children = append(children, &child{labelValues: labelValues, metric: sample(value)})
+		})
+	} else {
+		f.mutex.RLock()
+		for _, c := range f.children {
+			children = append(children, c)
+		}
+		f.mutex.RUnlock()
+	}
+	sort.Slice(children, func(i, j int) bool {
+		a, b := children[i].labelValues, children[j].labelValues
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return children
+}
+
+// Handler serves the registry, usually at /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			writer.Header().Set("Allow", "GET, HEAD")
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var buffer bytes.Buffer
+		r.WriteText(&buffer)
+		writer.Header().Set("Content-Type", ContentType)
+		if request.Method == http.MethodGet {
+			buffer.WriteTo(writer)
+		}
+	})
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func writeText(t *testing.T, registry *Registry) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := registry.WriteText(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
+
+func TestFuncRegisteredTwiceUsesTheLastCollect(t *testing.T) {
+	registry := NewRegistry()
+	registry.CounterFunc("worker_values_total", "Values.", []string{"worker"}, func(emit Emit) {
+		emit(1, "old")
+	})
+	registry.CounterFunc("worker_values_total", "Values.", []string{"worker"}, func(emit Emit) {
+		emit(2, "new")
+	})
+	text := writeText(t, registry)
+	if !strings.Contains(text, `worker_values_total{worker="new"} 2`) || strings.Contains(text, "old") {
+		t.Errorf("expected only the values of the second collect, got:\n%s", text)
+	}
+}
+
+func TestConflictingRegistrationPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"other kind", func(r *Registry) { r.Gauge("requests_total", "Requests.", "code") }},
+		{"other labels", func(r *Registry) { r.Counter("requests_total", "Requests.", "method") }},
+		{"func over a counter", func(r *Registry) { r.CounterFunc("requests_total", "Requests.", []string{"code"}, func(Emit) {}) }},
+		{"invalid name", func(r *Registry) { r.Counter("requests-total", "Requests.") }},
+		{"invalid label", func(r *Registry) { r.Counter("other_total", "Other.", "__code") }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Counter("requests_total", "Requests.", "code")
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			test.register(registry)
+		})
+	}
+}
@@ -1,17 +0,0 @@
-package main
-
-import "net/http"
-
-/*
-If you run it twice the panic will be called
- */
-func main() {
-	http.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
-		writer.Write([]byte("Hello Go!"))
-	})
-	err := http.ListenAndServe(":8080", nil)
-	if err != nil {
-		panic(err.Error())
-	}
-
-}
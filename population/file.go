@@ -0,0 +1,451 @@
+package population
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RowError is a row that was skipped while loading. Line counts from 1, like an editor does
+type RowError struct {
+	Line    int
+	Message string
+}
+
+func (re RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", re.Line, re.Message)
+}
+
+/*
+LoadCSV reads a file with a "state,population" header, columns in any order and extra columns ignored.
+Rows that cannot be used are skipped and returned as RowErrors, the rest is loaded. The error is for
+problems with the file as a whole, such as a missing header
+*/
+func LoadCSV(r io.Reader) (map[string]int, []RowError, error) {
+	result := map[string]int{}
+	lines := map[string]int{}
+	var rowErrors []RowError
+	stateColumn, populationColumn := -1, -1
+	err := readCSV(r, func(line int, record []string, err error) error {
+		if stateColumn < 0 { //the first record is the header
+			if err != nil {
+				return fmt.Errorf("population: CSV header: %v", err)
+			}
+			for i, name := range record {
+				name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) //spreadsheets like to start with a BOM
+				switch name {
+				case "state":
+					stateColumn = i
+				case "population":
+					populationColumn = i
+				}
+			}
+			if stateColumn < 0 || populationColumn < 0 {
+				return fmt.Errorf("population: CSV header %q must have a state and a population column", strings.Join(record, ","))
+			}
+			return nil
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Message: err.Error()})
+			return nil
+		}
+		if len(record) <= stateColumn || len(record) <= populationColumn {
+			rowErrors = append(rowErrors, RowError{Line: line, Message: fmt.Sprintf("expected at least %d columns, got %d", maxInt(stateColumn, populationColumn)+1, len(record))})
+			return nil
+		}
+		if rowErr := addRow(result, lines, line, record[stateColumn], record[populationColumn]); rowErr != nil {
+			rowErrors = append(rowErrors, *rowErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if stateColumn < 0 {
+		return nil, nil, errors.New("population: empty CSV, expected a state,population header")
+	}
+	return result, rowErrors, nil
+}
+
+// maxLineBytes is the longest line readCSV accepts, a longer one is skipped and reported as a RowError
+const maxLineBytes = 1024 * 1024
+
+/*
+readCSV calls fn for every record with the line it starts on. csv.Reader only knows the line of a parse error,
+so the lines are collected here, a quoted field spanning lines included, and parsed one record at a time.
+Blank lines are skipped. An error returned by fn stops the reading
+*/
+func readCSV(r io.Reader, fn func(line int, record []string, err error) error) error {
+	reader := bufio.NewReader(r)
+	records := &csvRecords{fn: fn}
+	line := 0
+	for {
+		text, tooLong, err := readLine(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("population: CSV: %v", err)
+		}
+		line++
+		if tooLong {
+			err = records.skip(line, fmt.Errorf("line is longer than %d bytes", maxLineBytes))
+		} else {
+			err = records.feed(csvLine{number: line, text: strings.TrimSuffix(text, "\r")})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return records.finish()
+}
+
+// readLine reads one line without its end of line. The rest of a line longer than maxLineBytes is discarded
+func readLine(reader *bufio.Reader) (text string, tooLong bool, err error) {
+	var line []byte
+	for started := false; ; started = true {
+		fragment, isPrefix, err := reader.ReadLine()
+		if err == io.EOF && started { //the last line filled the buffer exactly
+			return string(line), tooLong, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if len(line)+len(fragment) > maxLineBytes {
+			tooLong, line = true, nil
+		} else if !tooLong {
+			line = append(line, fragment...)
+		}
+		if !isPrefix {
+			return string(line), tooLong, nil
+		}
+	}
+}
+
+type csvLine struct {
+	number int
+	text   string
+}
+
+// csvRecords groups the physical lines into records, a line that opens a quoted field waits for the next ones
+type csvRecords struct {
+	fn       func(line int, record []string, err error) error
+	pending  []csvLine
+	inQuotes bool
+}
+
+func (cr *csvRecords) feed(line csvLine) error {
+	cr.pending = append(cr.pending, line)
+	cr.inQuotes = quoteOpen(line.text, cr.inQuotes)
+	if cr.inQuotes {
+		return nil
+	}
+	lines := cr.pending
+	cr.pending = nil
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	text := strings.Join(texts, "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.TrimLeadingSpace = true
+	record, err := reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		err = parseErr.Err
+	}
+	return cr.fn(lines[0].number, record, err)
+}
+
+// skip drops a line that cannot be read, together with the record it belongs to
+func (cr *csvRecords) skip(line int, err error) error {
+	if len(cr.pending) > 0 {
+		line = cr.pending[0].number
+	}
+	cr.pending, cr.inQuotes = nil, false
+	return cr.fn(line, nil, err)
+}
+
+/*
+finish handles a quoted field that is still open at the end of the file. Only the line that opened it is
+reported, the lines after it are read again on their own, so one stray quote does not swallow the rest of the file
+*/
+func (cr *csvRecords) finish() error {
+	for len(cr.pending) > 0 {
+		lines := cr.pending
+		cr.pending, cr.inQuotes = nil, false
+		if err := cr.fn(lines[0].number, nil, errors.New("quoted field is never closed")); err != nil {
+			return err
+		}
+		for _, line := range lines[1:] {
+			if err := cr.feed(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+quoteOpen tells whether a quoted field is still open at the end of text. Like csv.Reader, only a quote at the
+start of a field (after spaces) opens one and "" inside it is an escaped quote. A quote in the middle of a field,
+as in 27"232, is an error of that line only and does not continue the record
+*/
+func quoteOpen(text string, inQuotes bool) bool {
+	fieldStart := !inQuotes
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inQuotes:
+			if c == '"' {
+				if i+1 < len(text) && text[i+1] == '"' {
+					i++
+				} else {
+					inQuotes, fieldStart = false, false
+				}
+			}
+		case c == ',':
+			fieldStart = true
+		case c == '"' && fieldStart:
+			inQuotes = true
+		case c == ' ' || c == '\t':
+		default:
+			fieldStart = false
+		}
+	}
+	return inQuotes
+}
+
+/*
+LoadJSON reads an array of {"state": "...", "population": n} objects, the format of GET /populations.
+As with LoadCSV, bad elements are skipped and reported with the line they start on
+*/
+func LoadJSON(r io.Reader) (map[string]int, []RowError, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	lineAt := func(offset int64) int {
+		return 1 + bytes.Count(data[:offset], []byte("\n"))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, nil, errors.New("population: JSON must be an array of {\"state\", \"population\"} objects")
+	}
+	result := map[string]int{}
+	lines := map[string]int{}
+	var rowErrors []RowError
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, nil, fmt.Errorf("population: JSON line %d: %v", lineAt(decoder.InputOffset()), err)
+		}
+		//raw holds exactly the bytes of the element, which end at the current offset
+		line := lineAt(decoder.InputOffset() - int64(len(raw)))
+		var row struct {
+			State      *string      `json:"state"`
+			Population *json.Number `json:"population"`
+		}
+		rowDecoder := json.NewDecoder(bytes.NewReader(raw))
+		rowDecoder.UseNumber()
+		rowDecoder.DisallowUnknownFields()
+		if err := rowDecoder.Decode(&row); err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Message: err.Error()})
+			continue
+		}
+		if row.State == nil || row.Population == nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Message: "state and population are required"})
+			continue
+		}
+		if rowErr := addRow(result, lines, line, *row.State, row.Population.String()); rowErr != nil {
+			rowErrors = append(rowErrors, *rowErr)
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, fmt.Errorf("population: JSON: %v", err)
+	}
+	return result, rowErrors, nil
+}
+
+// addRow checks one row. lines remembers where each state was first seen, to point at duplicates
+func addRow(result map[string]int, lines map[string]int, line int, state, population string) *RowError {
+	state = strings.TrimSpace(state)
+	if state == "" {
+		return &RowError{Line: line, Message: "state is empty"}
+	}
+	digits, ok := ungroup(strings.TrimSpace(population))
+	if !ok {
+		return &RowError{Line: line, Message: fmt.Sprintf("population %q of %v has misplaced thousands separators", population, state)}
+	}
+	value, err := strconv.Atoi(digits)
+	if err != nil {
+		return &RowError{Line: line, Message: fmt.Sprintf("population %q of %v is not an integer", population, state)}
+	}
+	if value < 0 {
+		return &RowError{Line: line, Message: fmt.Sprintf("population of %v is negative", state)}
+	}
+	if first, ok := lines[state]; ok {
+		return &RowError{Line: line, Message: fmt.Sprintf("%v already appears on line %d", state, first)}
+	}
+	lines[state] = line
+	result[state] = value
+	return nil
+}
+
+/*
+ungroup removes the thousands separators of "39,250,018", "39 250 018" or "39_250_018", which is how spreadsheets
+write big numbers. One kind of separator is allowed per number, between groups of three digits.
+ok is false for anything else, such as "1,2,3" or "4_2"
+*/
+func ungroup(text string) (digits string, ok bool) {
+	at := strings.IndexAny(text, ", _")
+	if at < 0 {
+		return text, true
+	}
+	groups := strings.Split(text, text[at:at+1])
+	if first := strings.TrimLeft(groups[0], "+-"); len(first) == 0 || len(first) > 3 {
+		return "", false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return "", false
+		}
+	}
+	return strings.Join(groups, ""), true
+}
+
+// LoadFile picks LoadCSV or LoadJSON from the extension of path
+func LoadFile(path string) (map[string]int, []RowError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(file)
+	case ".json":
+		return LoadJSON(file)
+	}
+	return nil, nil, fmt.Errorf("population: %v: unknown format, use .csv or .json", path)
+}
+
+// SaveCSV writes a header and one row per state, ordered by name
+func SaveCSV(w io.Writer, populations map[string]int) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"state", "population"})
+	for _, row := range sortedRows(populations) {
+		writer.Write([]string{row.State, strconv.Itoa(row.Population)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SaveJSON writes the format LoadJSON reads, ordered by name
+func SaveJSON(w io.Writer, populations map[string]int) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sortedRows(populations))
+}
+
+/*
+SaveFile writes to a temporary file next to path and renames it over path once everything is on disk.
+A crash in the middle leaves the old file as it was, never a half written one.
+The new file keeps the permissions of the one it replaces, a file that did not exist gets 0644
+*/
+func SaveFile(path string, populations map[string]int) (err error) {
+	var save func(io.Writer, map[string]int) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		save = SaveCSV
+	case ".json":
+		save = SaveJSON
+	default:
+		return fmt.Errorf("population: %v: unknown format, use .csv or .json", path)
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			temp.Close()
+			os.Remove(temp.Name())
+		}
+	}()
+	if err = save(temp, populations); err != nil {
+		return err
+	}
+	if err = temp.Sync(); err != nil {
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+/*
+Load replaces the content of the store with the file. Rows that were skipped are returned.
+The differences with the current content go to the change log with "file <path>" as the actor
+*/
+func (s *Store) Load(path string) ([]RowError, error) {
+	populations, rowErrors, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	actor := "file " + path
+	for _, d := range diff(s.populations, populations) {
+		if d.Kind == Removed {
+			s.delete(actor, d.State)
+		} else {
+			s.set(actor, d.State, d.New)
+		}
+	}
+	return rowErrors, nil
+}
+
+// Save writes a snapshot of the store with SaveFile
+func (s *Store) Save(path string) error {
+	return SaveFile(path, s.Snapshot())
+}
+
+func sortedRows(populations map[string]int) []StatePopulation {
+	rows := make([]StatePopulation, 0, len(populations))
+	for state, population := range populations {
+		rows = append(rows, StatePopulation{State: state, Population: population})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].State < rows[j].State
+	})
+	return rows
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
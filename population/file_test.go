@@ -0,0 +1,154 @@
+package population
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      map[string]int
+		rowErrors []int // lines of the expected RowErrors
+	}{
+		{
+			name:  "plain",
+			input: "state,population\nTexas,27232432\nOhio,11632432\n",
+			want:  map[string]int{"Texas": 27232432, "Ohio": 11632432},
+		},
+		{
+			name:  "columns in any order, BOM, separators and extra columns",
+			input: "\ufeffcode,population,state\nTX,\"27,232,432\",Texas\r\nOH,11 632 432,Ohio\r\n",
+			want:  map[string]int{"Texas": 27232432, "Ohio": 11632432},
+		},
+		{
+			name:      "stray quote only fails its own line",
+			input:     "state,population\nTexas,27\"232\nOhio,11\nFlorida,20",
+			want:      map[string]int{"Ohio": 11, "Florida": 20},
+			rowErrors: []int{2},
+		},
+		{
+			name:      "quoted field never closed only fails its own line",
+			input:     "state,population\n\"Texas,27\nOhio,11\nFlorida,20\n",
+			want:      map[string]int{"Ohio": 11, "Florida": 20},
+			rowErrors: []int{2},
+		},
+		{
+			name:  "quoted field spanning lines",
+			input: "state,population\n\"New\nYork\",19\nOhio,11\n",
+			want:  map[string]int{"New\nYork": 19, "Ohio": 11},
+		},
+		{
+			name:      "separators only between groups of three digits",
+			input:     "state,population\nTexas,\"1,2,3\"\nOhio,4_2\nIowa,\"3,190,369\"\nUtah,\"3 271,616\"\nMaine,\",123\"\nIdaho,1_839_106\n",
+			want:      map[string]int{"Iowa": 3190369, "Idaho": 1839106},
+			rowErrors: []int{2, 3, 5, 6},
+		},
+		{
+			name:      "a line over the limit only fails its own line",
+			input:     "state,population\nTexas,27\nOhio," + strings.Repeat("1", maxLineBytes) + "\nFlorida,20\n",
+			want:      map[string]int{"Texas": 27, "Florida": 20},
+			rowErrors: []int{3},
+		},
+		{
+			name:  "a line at the limit is read",
+			input: "state,population\nOhio," + strings.Repeat("0", maxLineBytes-len("Ohio,1")) + "1\nFlorida,20",
+			want:  map[string]int{"Ohio": 1, "Florida": 20},
+		},
+		{
+			name:      "bad rows are reported with their line",
+			input:     "state,population\nTexas,many\n\n,3\nOhio,-1\nOhio,2\nOhio,4\nGeorgia\n",
+			want:      map[string]int{"Ohio": 2},
+			rowErrors: []int{2, 4, 5, 7, 8},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, rowErrors, err := LoadCSV(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("LoadCSV() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LoadCSV() = %v, want %v", got, test.want)
+			}
+			var lines []int
+			for _, rowErr := range rowErrors {
+				lines = append(lines, rowErr.Line)
+			}
+			if !reflect.DeepEqual(lines, test.rowErrors) {
+				t.Errorf("RowError lines = %v (%v), want %v", lines, rowErrors, test.rowErrors)
+			}
+		})
+	}
+}
+
+func TestLoadCSVHeader(t *testing.T) {
+	for _, input := range []string{"", "name,count\nTexas,1\n"} {
+		if _, _, err := LoadCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("LoadCSV(%q) error = nil, want an error", input)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	input := `[
+  {"state": "Texas", "population": 27232432},
+  {"state": "Ohio", "population": "many"},
+  {"state": "Florida"},
+  {"state": "Georgia", "population": 10310371, "extra": 1}
+]`
+	got, rowErrors, err := LoadJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadJSON() error = %v", err)
+	}
+	if want := map[string]int{"Texas": 27232432}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadJSON() = %v, want %v", got, want)
+	}
+	var lines []int
+	for _, rowErr := range rowErrors {
+		lines = append(lines, rowErr.Line)
+	}
+	if want := []int{3, 4, 5}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("RowError lines = %v (%v), want %v", lines, rowErrors, want)
+	}
+}
+
+func TestSaveFileMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "population")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	populations := map[string]int{"Texas": 27232432}
+
+	created := filepath.Join(dir, "created.csv")
+	if err := SaveFile(created, populations); err != nil {
+		t.Fatal(err)
+	}
+	replaced := filepath.Join(dir, "replaced.json")
+	if err := ioutil.WriteFile(replaced, []byte("[]"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFile(replaced, populations); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]os.FileMode{created: 0644, replaced: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%v mode = %v, want %v", filepath.Base(path), info.Mode().Perm(), want)
+		}
+		got, _, err := LoadFile(path)
+		if err != nil || !reflect.DeepEqual(got, populations) {
+			t.Errorf("LoadFile(%v) = %v, %v", filepath.Base(path), got, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package population
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"firstApp/server"
+	"firstApp/validation"
+)
+
+/*
+Handler serves the store as JSON:
+
+	GET                /populations          every state, by name
+	GET, PUT, DELETE   /populations/{state}  one state. PUT takes {"population": n}
+	GET                /population-stats     total, number of states, ?top=N and ?above=N
+
+Empty top and above lists are left out of the stats. Writes are recorded in the change log under the
+X-User header of the request
+*/
+type Handler struct {
+	store *Store
+}
+
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/populations", h.serveList)
+	mux.HandleFunc("/populations/", h.serveState)
+	mux.HandleFunc("/population-stats", h.serveStats)
+}
+
+func (h *Handler) serveList(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		server.MethodNotAllowed(writer, http.MethodGet)
+		return
+	}
+	server.WriteJSON(writer, http.StatusOK, h.store.All())
+}
+
+type populationBody struct {
+	Population int `json:"population" validate:"min=0"`
+}
+
+func (h *Handler) serveState(writer http.ResponseWriter, request *http.Request) {
+	state := strings.TrimPrefix(request.URL.Path, "/populations/")
+	if state == "" || strings.Contains(state, "/") {
+		server.WriteError(writer, http.StatusNotFound, "not found")
+		return
+	}
+	switch request.Method {
+	case http.MethodGet:
+		population, ok := h.store.Get(state)
+		if !ok {
+			server.WriteError(writer, http.StatusNotFound, "state not found")
+			return
+		}
+		server.WriteJSON(writer, http.StatusOK, StatePopulation{State: state, Population: population})
+	case http.MethodPut:
+		var body populationBody
+		if err := server.DecodeJSON(writer, request, &body); err != nil {
+			server.WriteError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := validation.Validate(body); err != nil {
+			server.WriteValidationError(writer, request, err)
+			return
+		}
+		h.store.SetBy(request.Header.Get("X-User"), state, body.Population)
+		server.WriteJSON(writer, http.StatusOK, StatePopulation{State: state, Population: body.Population})
+	case http.MethodDelete:
+		if !h.store.DeleteBy(request.Header.Get("X-User"), state) {
+			server.WriteError(writer, http.StatusNotFound, "state not found")
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		server.MethodNotAllowed(writer, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+type stats struct {
+	Total  int64             `json:"total"`
+	States int               `json:"states"`
+	Top    []StatePopulation `json:"top,omitempty"`
+	Above  []StatePopulation `json:"above,omitempty"`
+}
+
+func (h *Handler) serveStats(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		server.MethodNotAllowed(writer, http.MethodGet)
+		return
+	}
+	result := stats{Total: h.store.Total(), States: h.store.Len()}
+	query := request.URL.Query()
+	if text := query.Get("top"); text != "" {
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 0 {
+			server.WriteError(writer, http.StatusBadRequest, "top must be a positive integer")
+			return
+		}
+		result.Top = h.store.TopN(n)
+	}
+	if text := query.Get("above"); text != "" {
+		threshold, err := strconv.Atoi(text)
+		if err != nil {
+			server.WriteError(writer, http.StatusBadRequest, "above must be an integer")
+			return
+		}
+		result.Above = h.store.Above(threshold)
+	}
+	server.WriteJSON(writer, http.StatusOK, result)
+}
@@ -0,0 +1,107 @@
+package population
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var request *http.Request
+	if body == "" {
+		request = httptest.NewRequest(method, target, nil)
+	} else {
+		request = httptest.NewRequest(method, target, strings.NewReader(body))
+	}
+	request.Header.Set("X-User", "ann")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func newTestHandler() (http.Handler, *Store) {
+	store := NewStore(map[string]int{"Texas": 29, "Ohio": 11, "Iowa": 3})
+	mux := http.NewServeMux()
+	NewHandler(store).Register(mux)
+	return mux, store
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{"list", "GET", "/populations", "", 200,
+			`[{"state":"Iowa","population":3},{"state":"Ohio","population":11},{"state":"Texas","population":29}]`},
+		{"list method", "POST", "/populations", "", 405, `{"error":"method not allowed"}`},
+		{"get", "GET", "/populations/Ohio", "", 200, `{"state":"Ohio","population":11}`},
+		{"get missing", "GET", "/populations/Nowhere", "", 404, `{"error":"state not found"}`},
+		{"no state", "GET", "/populations/", "", 404, `{"error":"not found"}`},
+		{"nested path", "GET", "/populations/Ohio/x", "", 404, `{"error":"not found"}`},
+		{"put", "PUT", "/populations/Utah", `{"population": 3}`, 200, `{"state":"Utah","population":3}`},
+		{"put negative", "PUT", "/populations/Utah", `{"population": -1}`, 422, ""},
+		{"put unknown field", "PUT", "/populations/Utah", `{"people": 3}`, 400, ""},
+		{"put not JSON", "PUT", "/populations/Utah", `three`, 400, ""},
+		{"delete", "DELETE", "/populations/Iowa", "", 204, ""},
+		{"delete missing", "DELETE", "/populations/Nowhere", "", 404, `{"error":"state not found"}`},
+		{"state method", "POST", "/populations/Ohio", "", 405, `{"error":"method not allowed"}`},
+		{"stats", "GET", "/population-stats", "", 200, `{"total":43,"states":3}`},
+		{"stats top", "GET", "/population-stats?top=2", "", 200,
+			`{"total":43,"states":3,"top":[{"state":"Texas","population":29},{"state":"Ohio","population":11}]}`},
+		{"stats above", "GET", "/population-stats?above=10", "", 200,
+			`{"total":43,"states":3,"above":[{"state":"Texas","population":29},{"state":"Ohio","population":11}]}`},
+		{"stats empty above", "GET", "/population-stats?above=100", "", 200, `{"total":43,"states":3}`},
+		{"stats bad top", "GET", "/population-stats?top=x", "", 400, `{"error":"top must be a positive integer"}`},
+		{"stats negative top", "GET", "/population-stats?top=-1", "", 400, `{"error":"top must be a positive integer"}`},
+		{"stats bad above", "GET", "/population-stats?above=1.5", "", 400, `{"error":"above must be an integer"}`},
+		{"stats method", "DELETE", "/population-stats", "", 405, `{"error":"method not allowed"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, _ := newTestHandler()
+			recorder := serve(handler, test.method, test.target, test.body)
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			if test.want != "" {
+				if got := strings.TrimSpace(recorder.Body.String()); got != test.want {
+					t.Errorf("body = %s, want %s", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerWrites(t *testing.T) {
+	handler, store := newTestHandler()
+	serve(handler, "PUT", "/populations/Ohio", `{"population": 12}`)
+	serve(handler, "DELETE", "/populations/Iowa", "")
+
+	if population, _ := store.Get("Ohio"); population != 12 {
+		t.Errorf("Ohio = %d after PUT, want 12", population)
+	}
+	if _, ok := store.Get("Iowa"); ok {
+		t.Errorf("Iowa still in the store after DELETE")
+	}
+	changes := store.Changes(0)
+	if len(changes) != 2 || changes[0].Actor != "ann" || changes[1].Actor != "ann" {
+		t.Errorf("changes %+v, want two by ann", changes)
+	}
+}
+
+func TestHandlerValidationErrors(t *testing.T) {
+	handler, _ := newTestHandler()
+	recorder := serve(handler, "PUT", "/populations/Ohio", `{"population": -5}`)
+	var body struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || len(body.Errors) != 1 {
+		t.Errorf("body %s, want one field error (%v)", recorder.Body, err)
+	}
+}
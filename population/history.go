@@ -0,0 +1,258 @@
+package population
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ChangeKind int
+
+const (
+	SetChange ChangeKind = iota
+	DeleteChange
+)
+
+func (k ChangeKind) String() string {
+	if k == DeleteChange {
+		return "delete"
+	}
+	return "set"
+}
+
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+/*
+Change is one entry of the change log. Version is the version of the store right after the change.
+Old is only meaningful when Existed is true
+*/
+type Change struct {
+	Version int64      `json:"version"`
+	Time    time.Time  `json:"time"`
+	Actor   string     `json:"actor,omitempty"`
+	Kind    ChangeKind `json:"kind"`
+	State   string     `json:"state"`
+	Old     int        `json:"old"`
+	Existed bool       `json:"existed"`
+	New     int        `json:"new"`
+}
+
+/*
+Checkpoint is a frozen view of the store. It shares the map of the store until the next write, which copies
+the map (copy on write). So taking a checkpoint costs nothing, and a checkpoint never changes afterwards,
+unlike sp := statePopulations which is the same map under another name
+*/
+type Checkpoint struct {
+	ID      int
+	Version int64
+	Time    time.Time
+	data    map[string]int
+}
+
+func (cp *Checkpoint) Get(state string) (int, bool) {
+	population, ok := cp.data[state]
+	return population, ok
+}
+
+func (cp *Checkpoint) Len() int {
+	return len(cp.data)
+}
+
+// All returns every state ordered by name
+func (cp *Checkpoint) All() []StatePopulation {
+	return sortedRows(cp.data)
+}
+
+/*
+Checkpoint freezes the current content. IDs start at 1 and grow by one. Only the last MaxCheckpoints are kept,
+a checkpoint still in use by the caller stays valid but can no longer be found by ID
+*/
+func (s *Store) Checkpoint() *Checkpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastID++
+	cp := &Checkpoint{ID: s.lastID, Version: s.version, Time: s.now(), data: s.populations}
+	s.shared = true
+	s.checkpoints = append(s.checkpoints, cp)
+	if excess := len(s.checkpoints) - s.limits.MaxCheckpoints; excess > 0 {
+		s.checkpoints = append([]*Checkpoint(nil), s.checkpoints[excess:]...)
+	}
+	return cp
+}
+
+func (s *Store) CheckpointByID(id int) (*Checkpoint, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	i := s.checkpointIndex(id)
+	if i < 0 {
+		return nil, false
+	}
+	return s.checkpoints[i], true
+}
+
+// DropCheckpoint forgets a checkpoint, so its map can be freed. It reports whether the checkpoint was there
+func (s *Store) DropCheckpoint(id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := s.checkpointIndex(id)
+	if i < 0 {
+		return false
+	}
+	s.checkpoints = append(s.checkpoints[:i:i], s.checkpoints[i+1:]...)
+	return true
+}
+
+// checkpointIndex finds id in checkpoints, which is ordered by ID. -1 if it is not there
+func (s *Store) checkpointIndex(id int) int {
+	i := sort.Search(len(s.checkpoints), func(i int) bool {
+		return s.checkpoints[i].ID >= id
+	})
+	if i == len(s.checkpoints) || s.checkpoints[i].ID != id {
+		return -1
+	}
+	return i
+}
+
+// GetAsOf is Get on checkpoint id: the population of the state at the time the checkpoint was taken
+func (s *Store) GetAsOf(id int, state string) (int, bool, error) {
+	cp, ok := s.CheckpointByID(id)
+	if !ok {
+		return 0, false, fmt.Errorf("population: no checkpoint %d", id)
+	}
+	population, ok := cp.Get(state)
+	return population, ok, nil
+}
+
+/*
+Changes returns the log entries with a version greater than since. Changes(0) is the whole log, which holds
+the last MaxChanges entries: when since is older than that, the first entry tells where the log starts
+*/
+func (s *Store) Changes(since int64) []Change {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	i := sort.Search(len(s.changes), func(i int) bool {
+		return s.changes[i].Version > since
+	})
+	return append([]Change(nil), s.changes[i:]...)
+}
+
+// Version counts the changes made to the store so far
+func (s *Store) Version() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.version
+}
+
+type DifferenceKind int
+
+const (
+	Added DifferenceKind = iota
+	Removed
+	Changed
+)
+
+func (k DifferenceKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return "changed"
+}
+
+func (k DifferenceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Difference of one state between two checkpoints. Old is zero for Added, New is zero for Removed
+type Difference struct {
+	State string         `json:"state"`
+	Kind  DifferenceKind `json:"kind"`
+	Old   int            `json:"old"`
+	New   int            `json:"new"`
+}
+
+// Diff lists what changed from one checkpoint to the other, ordered by state
+func Diff(from, to *Checkpoint) []Difference {
+	return diff(from.data, to.data)
+}
+
+// Diff compares two checkpoints of the store by ID
+func (s *Store) Diff(fromID, toID int) ([]Difference, error) {
+	from, ok := s.CheckpointByID(fromID)
+	if !ok {
+		return nil, fmt.Errorf("population: no checkpoint %d", fromID)
+	}
+	to, ok := s.CheckpointByID(toID)
+	if !ok {
+		return nil, fmt.Errorf("population: no checkpoint %d", toID)
+	}
+	return Diff(from, to), nil
+}
+
+func diff(from, to map[string]int) []Difference {
+	var result []Difference
+	for state, old := range from {
+		if population, ok := to[state]; !ok {
+			result = append(result, Difference{State: state, Kind: Removed, Old: old})
+		} else if population != old {
+			result = append(result, Difference{State: state, Kind: Changed, Old: old, New: population})
+		}
+	}
+	for state, population := range to {
+		if _, ok := from[state]; !ok {
+			result = append(result, Difference{State: state, Kind: Added, New: population})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].State < result[j].State
+	})
+	return result
+}
+
+// set and delete expect the write lock to be held
+
+func (s *Store) set(actor, state string, population int) {
+	old, existed := s.populations[state]
+	s.writable()
+	s.populations[state] = population
+	s.record(Change{Actor: actor, Kind: SetChange, State: state, Old: old, Existed: existed, New: population})
+}
+
+func (s *Store) delete(actor, state string) bool {
+	old, existed := s.populations[state]
+	if !existed {
+		return false
+	}
+	s.writable()
+	delete(s.populations, state)
+	s.record(Change{Actor: actor, Kind: DeleteChange, State: state, Old: old, Existed: true})
+	return true
+}
+
+// writable copies the map if a checkpoint still points at it
+func (s *Store) writable() {
+	if !s.shared {
+		return
+	}
+	populations := make(map[string]int, len(s.populations))
+	for state, population := range s.populations {
+		populations[state] = population
+	}
+	s.populations = populations
+	s.shared = false
+}
+
+func (s *Store) record(change Change) {
+	s.version++
+	change.Version = s.version
+	change.Time = s.now()
+	s.changes = append(s.changes, change)
+	if len(s.changes) > s.limits.MaxChanges {
+		//the array is not copied here, append moves only the entries still in the log once it is full
+		s.changes = s.changes[len(s.changes)-s.limits.MaxChanges:]
+	}
+}
@@ -0,0 +1,150 @@
+package population
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckpointIsFrozen(t *testing.T) {
+	store := NewStore(map[string]int{"Ohio": 11, "Texas": 27})
+	before := store.Checkpoint()
+	store.Set("Ohio", 12)
+	store.Delete("Texas")
+	store.Set("Florida", 20)
+	after := store.Checkpoint()
+
+	if got, _ := before.Get("Ohio"); got != 11 {
+		t.Errorf("Ohio in the first checkpoint = %d, want 11", got)
+	}
+	if _, ok := before.Get("Texas"); !ok {
+		t.Error("Texas is missing from the first checkpoint")
+	}
+	if before.Len() != 2 || after.Len() != 2 {
+		t.Errorf("Len() = %d and %d, want 2 and 2", before.Len(), after.Len())
+	}
+	want := []Difference{
+		{State: "Florida", Kind: Added, New: 20},
+		{State: "Ohio", Kind: Changed, Old: 11, New: 12},
+		{State: "Texas", Kind: Removed, Old: 27},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got, err := store.Diff(before.ID, after.ID); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Store.Diff() = %+v, %v, want %+v", got, err, want)
+	}
+	if population, ok, err := store.GetAsOf(before.ID, "Ohio"); err != nil || !ok || population != 11 {
+		t.Errorf("GetAsOf(%d, Ohio) = %d, %v, %v, want 11, true, nil", before.ID, population, ok, err)
+	}
+}
+
+// run with -race: readers of a checkpoint must never see the writes that come after it
+func TestCheckpointConcurrentWrites(t *testing.T) {
+	store := NewStore(map[string]int{"Ohio": 0})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 1; i <= 200; i++ {
+				store.SetBy(fmt.Sprint("writer", w), "Ohio", i)
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				cp := store.Checkpoint()
+				first, _ := cp.Get("Ohio")
+				for j := 0; j < 10; j++ {
+					if again, _ := cp.Get("Ohio"); again != first {
+						t.Errorf("checkpoint %d changed from %d to %d", cp.ID, first, again)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := store.Version(); got != 800 {
+		t.Errorf("Version() = %d, want 800", got)
+	}
+}
+
+func TestHistoryLimits(t *testing.T) {
+	store := NewStore(nil)
+	store.SetHistoryLimits(HistoryLimits{MaxChanges: 3, MaxCheckpoints: 2})
+	for i := 1; i <= 5; i++ {
+		store.Set("Ohio", i)
+		store.Checkpoint()
+	}
+
+	changes := store.Changes(0)
+	var versions []int64
+	for _, change := range changes {
+		versions = append(versions, change.Version)
+	}
+	if want := []int64{3, 4, 5}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("versions in the change log = %v, want %v", versions, want)
+	}
+	if got := store.Changes(4); len(got) != 1 || got[0].New != 5 {
+		t.Errorf("Changes(4) = %+v, want the change to 5", got)
+	}
+
+	tests := []struct {
+		id    int
+		found bool
+	}{
+		{1, false}, {3, false}, {4, true}, {5, true}, {6, false},
+	}
+	for _, test := range tests {
+		if _, found := store.CheckpointByID(test.id); found != test.found {
+			t.Errorf("CheckpointByID(%d) found = %v, want %v", test.id, found, test.found)
+		}
+	}
+	if !store.DropCheckpoint(4) || store.DropCheckpoint(4) {
+		t.Error("DropCheckpoint(4) should succeed once")
+	}
+	if _, found := store.CheckpointByID(5); !found {
+		t.Error("checkpoint 5 is gone after dropping 4")
+	}
+	if _, _, err := store.GetAsOf(4, "Ohio"); err == nil {
+		t.Error("GetAsOf on a dropped checkpoint did not fail")
+	}
+}
+
+func TestSnapshotIsACopy(t *testing.T) {
+	store := NewStore(map[string]int{"Ohio": 11})
+	snapshot := store.Snapshot()
+	snapshot["Ohio"] = 0
+	if got, _ := store.Get("Ohio"); got != 11 {
+		t.Errorf("changing the snapshot changed the store: Ohio = %d", got)
+	}
+}
+
+func TestChangeJSONKeepsZeroValues(t *testing.T) {
+	store := NewStore(nil)
+	store.Set("Ohio", 0)
+	store.Set("Ohio", 11)
+	store.Delete("Ohio")
+	want := []string{
+		`"kind":"set","state":"Ohio","old":0,"existed":false,"new":0}`,
+		`"kind":"set","state":"Ohio","old":0,"existed":true,"new":11}`,
+		`"kind":"delete","state":"Ohio","old":11,"existed":true,"new":0}`,
+	}
+	for i, change := range store.Changes(0) {
+		data, err := json.Marshal(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), want[i]) {
+			t.Errorf("change %d = %s, want it to end with %s", i, data, want[i])
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package population
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatePopulation is one row of the store, as returned by the queries
+type StatePopulation struct {
+	State      string `json:"state"`
+	Population int    `json:"population"`
+}
+
+/*
+Store is statePopulations behind a RWMutex. Many readers, one writer at a time.
+Maps are reference types, so the store never hands out its own map: Snapshot returns a copy.
+Every write is recorded in a change log, and Checkpoint freezes the current map (see history.go)
+*/
+type Store struct {
+	mutex       sync.RWMutex
+	populations map[string]int
+	// shared is true when a checkpoint holds populations. The next write copies the map first
+	shared      bool
+	version     int64
+	changes     []Change
+	checkpoints []*Checkpoint
+	lastID      int
+	limits      HistoryLimits
+	now         func() time.Time
+}
+
+// HistoryLimits bound the memory of a long running store. Zero fields get the defaults
+type HistoryLimits struct {
+	// MaxChanges is how many entries of the change log are kept, the oldest go first
+	MaxChanges int
+	// MaxCheckpoints is how many checkpoints are kept, each one may hold a full copy of the map
+	MaxCheckpoints int
+}
+
+const (
+	DefaultMaxChanges     = 10000
+	DefaultMaxCheckpoints = 100
+)
+
+// NewStore copies initial, later changes to initial do not reach the store
+func NewStore(initial map[string]int) *Store {
+	s := &Store{populations: make(map[string]int, len(initial)), now: time.Now}
+	s.SetHistoryLimits(HistoryLimits{})
+	for state, population := range initial {
+		s.populations[state] = population
+	}
+	return s
+}
+
+// Get has the comma ok semantics of a map lookup: ok is false for a missing (or misspelled) state
+func (s *Store) Get(state string) (int, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	population, ok := s.populations[state]
+	return population, ok
+}
+
+func (s *Store) Set(state string, population int) {
+	s.SetBy("", state, population)
+}
+
+// SetBy is Set with the name of who made the change, for the change log
+func (s *Store) SetBy(actor, state string, population int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(actor, state, population)
+}
+
+// Delete reports whether the state was there
+func (s *Store) Delete(state string) bool {
+	return s.DeleteBy("", state)
+}
+
+func (s *Store) DeleteBy(actor, state string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.delete(actor, state)
+}
+
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.populations)
+}
+
+/*
+SetHistoryLimits changes how much history is kept. Lower limits apply from the next change or checkpoint
+*/
+func (s *Store) SetHistoryLimits(limits HistoryLimits) {
+	if limits.MaxChanges <= 0 {
+		limits.MaxChanges = DefaultMaxChanges
+	}
+	if limits.MaxCheckpoints <= 0 {
+		limits.MaxCheckpoints = DefaultMaxCheckpoints
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limits = limits
+}
+
+// Snapshot returns a copy of the data
+func (s *Store) Snapshot() map[string]int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make(map[string]int, len(s.populations))
+	for state, population := range s.populations {
+		result[state] = population
+	}
+	return result
+}
+
+// All returns every state ordered by name
+func (s *Store) All() []StatePopulation {
+	rows := s.rows()
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].State < rows[j].State
+	})
+	return rows
+}
+
+// Total is the sum of all populations. int64 so the sum does not overflow where int is 32 bits
+func (s *Store) Total() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var total int64
+	for _, population := range s.populations {
+		total += int64(population)
+	}
+	return total
+}
+
+// TopN returns the n most populated states, largest first. Ties are ordered by name
+func (s *Store) TopN(n int) []StatePopulation {
+	rows := s.rows()
+	sortByPopulation(rows)
+	if n < 0 {
+		n = 0
+	}
+	if n < len(rows) {
+		rows = rows[:n]
+	}
+	return rows
+}
+
+// Above returns the states with a population strictly greater than threshold, largest first
+func (s *Store) Above(threshold int) []StatePopulation {
+	var rows []StatePopulation
+	for _, row := range s.rows() {
+		if row.Population > threshold {
+			rows = append(rows, row)
+		}
+	}
+	sortByPopulation(rows)
+	return rows
+}
+
+func (s *Store) rows() []StatePopulation {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rows := make([]StatePopulation, 0, len(s.populations))
+	for state, population := range s.populations {
+		rows = append(rows, StatePopulation{State: state, Population: population})
+	}
+	return rows
+}
+
+func sortByPopulation(rows []StatePopulation) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Population != rows[j].Population {
+			return rows[i].Population > rows[j].Population
+		}
+		return rows[i].State < rows[j].State
+	})
+}
@@ -0,0 +1,76 @@
+package population
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStoreQueries(t *testing.T) {
+	store := NewStore(map[string]int{
+		"Texas": 29, "Ohio": 11, "Iowa": 3, "Utah": 3, "Maine": 1,
+	})
+	tests := []struct {
+		name string
+		got  []StatePopulation
+		want []StatePopulation
+	}{
+		{"TopN", store.TopN(2), []StatePopulation{{"Texas", 29}, {"Ohio", 11}}},
+		{"TopN orders ties by name", store.TopN(4),
+			[]StatePopulation{{"Texas", 29}, {"Ohio", 11}, {"Iowa", 3}, {"Utah", 3}}},
+		{"TopN larger than the store", store.TopN(10),
+			[]StatePopulation{{"Texas", 29}, {"Ohio", 11}, {"Iowa", 3}, {"Utah", 3}, {"Maine", 1}}},
+		{"TopN of zero", store.TopN(0), []StatePopulation{}},
+		{"TopN of a negative number", store.TopN(-1), []StatePopulation{}},
+		{"Above is strict", store.Above(3), []StatePopulation{{"Texas", 29}, {"Ohio", 11}}},
+		{"Above with ties", store.Above(2), []StatePopulation{{"Texas", 29}, {"Ohio", 11}, {"Iowa", 3}, {"Utah", 3}}},
+		{"Above everything", store.Above(29), nil},
+		{"All is by name", store.All(),
+			[]StatePopulation{{"Iowa", 3}, {"Maine", 1}, {"Ohio", 11}, {"Texas", 29}, {"Utah", 3}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.got, test.want) {
+				t.Errorf("got %v, want %v", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestStoreTotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial map[string]int
+		want    int64
+	}{
+		{"empty", nil, 0},
+		{"sum", map[string]int{"Texas": 29, "Ohio": 11}, 40},
+		{"does not overflow int32", map[string]int{"a": 1 << 30, "b": 1 << 30, "c": 1 << 30}, 3 << 30},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NewStore(test.initial).Total(); got != test.want {
+				t.Errorf("Total = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStoreWrites(t *testing.T) {
+	initial := map[string]int{"Texas": 29}
+	store := NewStore(initial)
+	initial["Texas"] = 0
+	if population, _ := store.Get("Texas"); population != 29 {
+		t.Errorf("NewStore did not copy its input, Texas = %d", population)
+	}
+
+	store.Set("Ohio", 11)
+	if population, ok := store.Get("Ohio"); !ok || population != 11 {
+		t.Errorf("Get(Ohio) = %d, %v after Set", population, ok)
+	}
+	if !store.Delete("Ohio") || store.Delete("Ohio") {
+		t.Errorf("Delete should succeed once")
+	}
+	if _, ok := store.Get("Ohio"); ok || store.Len() != 1 {
+		t.Errorf("Ohio still in the store, Len = %d", store.Len())
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config of a Server. Addr may use port 0, the bound address is then available from Server.Addr
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish after a shutdown signal
+	ShutdownTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		ShutdownTimeout:   15 * time.Second,
+	}
+}
+
+/*
+ConfigFromFlags reads the configuration from the command line. Every flag defaults to an environment variable
+(SERVER_ADDR, SERVER_READ_TIMEOUT, ...) and then to DefaultConfig, so flags win over the environment
+*/
+func ConfigFromFlags(fs *flag.FlagSet, args []string) (Config, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	fs.StringVar(&config.Addr, "addr", config.Addr, "listen address, port 0 picks a free port (SERVER_ADDR)")
+	fs.DurationVar(&config.ReadHeaderTimeout, "read-header-timeout", config.ReadHeaderTimeout, "time to read request headers (SERVER_READ_HEADER_TIMEOUT)")
+	fs.DurationVar(&config.ReadTimeout, "read-timeout", config.ReadTimeout, "time to read a whole request (SERVER_READ_TIMEOUT)")
+	fs.DurationVar(&config.WriteTimeout, "write-timeout", config.WriteTimeout, "time to write a response (SERVER_WRITE_TIMEOUT)")
+	fs.DurationVar(&config.IdleTimeout, "idle-timeout", config.IdleTimeout, "keep-alive time between requests (SERVER_IDLE_TIMEOUT)")
+	fs.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", config.ShutdownTimeout, "time in-flight requests get on shutdown (SERVER_SHUTDOWN_TIMEOUT)")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// ConfigFromEnv is DefaultConfig overridden by the SERVER_* environment variables that are set
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+	if addr, ok := os.LookupEnv("SERVER_ADDR"); ok {
+		config.Addr = addr
+	}
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"SERVER_READ_HEADER_TIMEOUT", &config.ReadHeaderTimeout},
+		{"SERVER_READ_TIMEOUT", &config.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", &config.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", &config.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", &config.ShutdownTimeout},
+	}
+	for _, d := range durations {
+		text, ok := os.LookupEnv(d.name)
+		if !ok {
+			continue
+		}
+		value, err := time.ParseDuration(text)
+		if err != nil {
+			return Config{}, fmt.Errorf("server: %v: %v", d.name, err)
+		}
+		*d.value = value
+	}
+	return config, nil
+}
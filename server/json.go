@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"firstApp/validation"
+)
+
+// MaxBodyBytes limits the JSON bodies read by DecodeJSON
+const MaxBodyBytes = 1 << 20
+
+// WriteJSON answers with value encoded as JSON
+func WriteJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
+
+// WriteError answers with {"error": message}
+func WriteError(writer http.ResponseWriter, status int, message string) {
+	WriteJSON(writer, status, map[string]string{"error": message})
+}
+
+/*
+WriteValidationError answers 422 with the field errors translated to the Accept-Language of the request.
+Errors that do not come from the validation package are answered with 500
+*/
+func WriteValidationError(writer http.ResponseWriter, request *http.Request, err error) {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		WriteError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	lang := validation.MatchLanguage(request.Header.Get("Accept-Language"))
+	WriteJSON(writer, errs.StatusCode(), map[string]interface{}{"errors": errs.Localize(lang)})
+}
+
+// DecodeJSON reads a single JSON value of at most MaxBodyBytes. Unknown fields are an error
+func DecodeJSON(writer http.ResponseWriter, request *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid JSON body: more than one value")
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid JSON body: unexpected data after the value")
+	}
+	return nil
+}
+
+// MethodNotAllowed answers 405 with the Allow header listing what is supported
+func MethodNotAllowed(writer http.ResponseWriter, allowed ...string) {
+	for _, method := range allowed {
+		writer.Header().Add("Allow", method)
+	}
+	WriteError(writer, http.StatusMethodNotAllowed, "method not allowed")
+}
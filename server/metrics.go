@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"firstApp/metrics"
+)
+
+/*
+Metrics counts requests by route, method and status, measures their latency and tracks the requests in flight.
+route names the handler of a request and must return a small set of values, a raw path like /doctors/3 would
+create a new series per doctor. Pass the pattern of the ServeMux (see MuxRoute), nil leaves the route empty.
+Put it outside of Wrap so that it sees the 500 written by Recover
+*/
+func Metrics(registry *metrics.Registry, route func(*http.Request) string) Middleware {
+	requests := registry.Counter("http_requests_total", "HTTP requests by route, method and status code.", "route", "method", "code")
+	durations := registry.Histogram("http_request_duration_seconds", "HTTP request latency by route and method.", nil, "route", "method")
+	inFlight := registry.Gauge("http_requests_in_flight", "HTTP requests being served.").With()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			inFlight.Inc()
+			defer inFlight.Dec()
+			recorder := wrapWriter(writer)
+			next.ServeHTTP(recorder, request)
+			name := ""
+			if route != nil {
+				name = route(request)
+			}
+			requests.With(name, method(request), strconv.Itoa(recorder.status)).Inc()
+			durations.With(name, method(request)).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// MuxRoute returns the pattern of mux that serves the request, like /doctors/
+func MuxRoute(mux *http.ServeMux) func(*http.Request) string {
+	return func(request *http.Request) string {
+		_, pattern := mux.Handler(request)
+		return pattern
+	}
+}
+
+// method keeps the standard methods, any other is sent by a client and would be one more series per value
+func method(request *http.Request) string {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return request.Method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"firstApp/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/doctors/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	logger, _ := newTestLogger()
+	defer logger.Close(context.Background())
+	handler := Chain(Wrap(mux, logger), Metrics(registry, MuxRoute(mux)))
+	for _, path := range []string{"/doctors/1", "/doctors/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	request := httptest.NewRequest("BREW", "/doctors/1", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	var text bytes.Buffer
+	registry.WriteText(&text)
+	for _, want := range []string{
+		`http_requests_total{route="/doctors/",method="GET",code="404"} 2`,
+		`http_requests_total{route="/doctors/",method="OTHER",code="404"} 1`,
+		`http_request_duration_seconds_count{route="/doctors/",method="GET"} 2`,
+		`http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("missing %v in:\n%s", want, text.String())
+		}
+	}
+}
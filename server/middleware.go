@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"firstApp/logging"
+)
+
+// Middleware wraps a handler with extra behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies the middleware so that the first one given is the outermost
+func Chain(handler http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+/*
+Wrap is the standard stack: request IDs, one access log line per request and panic recovery.
+Recovery is the innermost, so the access log sees the 500 it writes
+*/
+func Wrap(handler http.Handler, logger *logging.Logger) http.Handler {
+	return Chain(handler, RequestID(), AccessLog(logger), Recover(logger))
+}
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// RequestIDHeader is read from the request when the client (or a proxy) already set it, and always answered
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID gives every request an ID, available to handlers through RequestIDFrom
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			id := request.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 128 {
+				id = newRequestID()
+			}
+			writer.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// RequestIDFrom returns the ID set by the RequestID middleware, or "" outside of it
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id[:])
+}
+
+/*
+Recover is the defer/recover pattern of panicker() for handlers. The panic value and the stack go to the
+logger, the client gets a 500 with the request ID to quote. http.ErrAbortHandler is passed on, because it
+is the way net/http aborts a response on purpose
+*/
+func Recover(logger *logging.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			recorder := wrapWriter(writer)
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				id := RequestIDFrom(request.Context())
+				logger.Error("handler panicked",
+					logging.F("request_id", id),
+					logging.F("method", request.Method),
+					logging.F("path", request.URL.Path),
+					logging.F("panic", fmt.Sprint(err)),
+					logging.F("stack", string(debug.Stack())),
+				)
+				if !recorder.wroteHeader {
+					http.Error(recorder, fmt.Sprintf("internal server error (request id %v)", id), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(recorder, request)
+		})
+	}
+}
+
+// AccessLog writes one line per request with method, path, status, bytes and latency
+func AccessLog(logger *logging.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			recorder := wrapWriter(writer)
+			next.ServeHTTP(recorder, request)
+			logger.Info("request",
+				logging.F("request_id", RequestIDFrom(request.Context())),
+				logging.F("method", request.Method),
+				logging.F("path", request.URL.Path),
+				logging.F("status", recorder.status),
+				logging.F("bytes", recorder.bytes),
+				logging.F("latency", time.Since(start)),
+			)
+		})
+	}
+}
+
+// statusRecorder remembers what the handler wrote, for the middleware above
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// wrapWriter reuses an existing recorder, so stacked middleware count the same bytes once
+func wrapWriter(writer http.ResponseWriter) *statusRecorder {
+	if recorder, ok := writer.(*statusRecorder); ok {
+		return recorder
+	}
+	return &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.wroteHeader {
+		return
+	}
+	sr.status = status
+	sr.wroteHeader = true
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(data []byte) (int, error) {
+	sr.wroteHeader = true
+	n, err := sr.ResponseWriter.Write(data)
+	sr.bytes += int64(n)
+	return n, err
+}
+
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		sr.wroteHeader = true
+		flusher.Flush()
+	}
+}
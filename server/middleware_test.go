@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"firstApp/logging"
+)
+
+func newTestLogger() (*logging.Logger, *logging.RingSink) {
+	ring := logging.NewRingSink(100)
+	return logging.New(logging.Config{Sinks: []logging.Sink{ring}}), ring
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		status     int
+		body       string
+		logMessage string
+	}{
+		{"ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) }, http.StatusOK, "hello", "request"},
+		{"panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, "internal server error", "handler panicked"},
+		{"panic after the header", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}, http.StatusAccepted, "", "handler panicked"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, ring := newTestLogger()
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/path", nil)
+			request.Header.Set(RequestIDHeader, "abc")
+			Wrap(test.handler, logger).ServeHTTP(recorder, request)
+			logger.Close(context.Background())
+
+			if recorder.Code != test.status || !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("answer %d %q, want %d %q", recorder.Code, recorder.Body.String(), test.status, test.body)
+			}
+			if got := recorder.Header().Get(RequestIDHeader); got != "abc" {
+				t.Errorf("%v = %q, want the one of the request", RequestIDHeader, got)
+			}
+			entries := ring.Entries()
+			if len(entries) == 0 || entries[0].Message != test.logMessage {
+				t.Fatalf("log %+v, want %q first", entries, test.logMessage)
+			}
+			last := entries[len(entries)-1]
+			if last.Message != "request" || !hasField(last, "status", test.status) || !hasField(last, "request_id", "abc") {
+				t.Errorf("access log %+v, want status %d and request_id abc", last, test.status)
+			}
+		})
+	}
+}
+
+func hasField(entry logging.Entry, key string, value interface{}) bool {
+	for _, field := range entry.Fields {
+		if field.Key == key {
+			return field.Value == value
+		}
+	}
+	return false
+}
+
+func TestRequestIDIsGenerated(t *testing.T) {
+	var seen string
+	handler := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	}))
+	for _, header := range []string{"", strings.Repeat("x", 129)} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set(RequestIDHeader, header)
+		handler.ServeHTTP(recorder, request)
+		if seen == "" || seen == header || recorder.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("request ID header %q: handler saw %q, answered %q", header, seen, recorder.Header().Get(RequestIDHeader))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+/*
+Server wraps http.Server. Start binds the port first, so a port already in use is an error returned to
+the caller instead of a panic, and the bound address is known before the first request
+*/
+type Server struct {
+	config     Config
+	httpServer *http.Server
+	listener   net.Listener
+	serveErr   chan error
+}
+
+func New(config Config, handler http.Handler) *Server {
+	return &Server{
+		config: config,
+		httpServer: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			ReadTimeout:       config.ReadTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		},
+		serveErr: make(chan error, 1),
+	}
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	if s.listener != nil {
+		return errors.New("server: already started")
+	}
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("server: cannot listen on %v: %w", s.config.Addr, err)
+	}
+	s.listener = listener
+	go func() {
+		err := s.httpServer.Serve(listener)
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		s.serveErr <- err
+	}()
+	return nil
+}
+
+// Addr is the address the server is bound to, with the real port when the configuration asked for port 0
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.config.Addr
+	}
+	return s.listener.Addr().String()
+}
+
+/*
+Wait blocks until ctx is done (a shutdown signal) or the server fails. On ctx it stops accepting connections
+and gives in-flight requests ShutdownTimeout to finish
+*/
+func (s *Server) Wait(ctx context.Context) error {
+	select {
+	case err := <-s.serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server: shutdown: %w", err)
+	}
+	return <-s.serveErr
+}
+
+// Run is Start followed by Wait
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.Start(); err != nil {
+		return err
+	}
+	return s.Wait(ctx)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerStartAndShutdown(t *testing.T) {
+	config := DefaultConfig()
+	config.Addr = "127.0.0.1:0"
+	released := make(chan struct{})
+	srv := New(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-released // an in-flight request when the shutdown starts
+		w.Write([]byte("done"))
+	}))
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(srv.Addr(), ":0") {
+		t.Fatalf("Addr() = %v, want the bound port", srv.Addr())
+	}
+
+	// a second server on the same port fails instead of panicking
+	config.Addr = srv.Addr()
+	if err := New(config, http.NotFoundHandler()).Start(); err == nil {
+		t.Error("Start() on a port in use did not fail")
+	}
+
+	answer := make(chan string, 1)
+	go func() {
+		response, err := http.Get("http://" + srv.Addr())
+		if err != nil {
+			answer <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := ioutil.ReadAll(response.Body)
+		answer <- string(body)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, stop := context.WithCancel(context.Background())
+	stop()
+	waited := make(chan error, 1)
+	go func() {
+		waited <- srv.Wait(ctx)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(released)
+	if err := <-waited; err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if got := <-answer; got != "done" {
+		t.Errorf("in-flight request got %q, want done", got)
+	}
+}
+
+func TestConfigFromFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    func(c *Config)
+		wantErr bool
+	}{
+		{"defaults", nil, nil, func(c *Config) {}, false},
+		{"environment", map[string]string{"SERVER_ADDR": ":9000", "SERVER_READ_TIMEOUT": "3s"}, nil,
+			func(c *Config) { c.Addr, c.ReadTimeout = ":9000", 3*time.Second }, false},
+		{"flags win over the environment", map[string]string{"SERVER_ADDR": ":9000"}, []string{"-addr", ":9001", "-shutdown-timeout", "1s"},
+			func(c *Config) { c.Addr, c.ShutdownTimeout = ":9001", time.Second }, false},
+		{"invalid duration", map[string]string{"SERVER_IDLE_TIMEOUT": "soon"}, nil, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				os.Setenv(name, value)
+			}
+			defer func() {
+				for name := range test.env {
+					os.Unsetenv(name)
+				}
+			}()
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			got, err := ConfigFromFlags(fs, test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Error("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := DefaultConfig()
+			test.want(&want)
+			if got != want {
+				t.Errorf("ConfigFromFlags() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	DefaultRelativeAccuracy = 0.01
+	DefaultMaxBuckets       = 2048
+)
+
+type Config struct {
+	// RelativeAccuracy of the percentiles, 0.01 means within 1% of the real value. Default 0.01
+	RelativeAccuracy float64
+	// MaxBuckets bounds the memory of the percentiles. Default 2048, which covers 1e-9 to 1e9 at 1%
+	MaxBuckets int
+}
+
+// Summary is what an Aggregator has seen so far. The percentiles are approximate, everything else is exact
+type Summary struct {
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	// Variance of the population (divided by Count, not Count-1)
+	Variance float64 `json:"variance"`
+	StdDev   float64 `json:"stdDev"`
+	P50      float64 `json:"p50"`
+	P90      float64 `json:"p90"`
+	P95      float64 `json:"p95"`
+	P99      float64 `json:"p99"`
+	// Skipped counts NaN and infinite values, they are not part of any other statistic
+	Skipped int64 `json:"skipped,omitempty"`
+}
+
+/*
+Aggregator computes statistics over a stream of values in constant memory. It can be shared between goroutines,
+or every worker can have its own and Merge them at the end, which gives the same result without contention.
+Two aggregators can be merged only when they have the same RelativeAccuracy
+*/
+type Aggregator struct {
+	mutex sync.RWMutex
+	state
+}
+
+type state struct {
+	accuracy float64
+	count    int64
+	sum      float64
+	// compensation of the sum (Neumaier), the low order bits lost by adding floats of different magnitude
+	compensation float64
+	min, max     float64
+	// mean and m2 (sum of squared distances from the mean) are updated with Welford's algorithm
+	mean, m2 float64
+	skipped  int64
+	sketch   *sketch
+}
+
+func New(config Config) *Aggregator {
+	if config.RelativeAccuracy <= 0 || config.RelativeAccuracy >= 1 {
+		config.RelativeAccuracy = DefaultRelativeAccuracy
+	}
+	if config.MaxBuckets < 2 {
+		config.MaxBuckets = DefaultMaxBuckets
+	}
+	return &Aggregator{state: state{
+		accuracy: config.RelativeAccuracy,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+		sketch:   newSketch(config.RelativeAccuracy, config.MaxBuckets),
+	}}
+}
+
+// Add adds one value. NaN and infinities are only counted as skipped
+func (a *Aggregator) Add(v float64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.add(v)
+}
+
+func (a *Aggregator) AddInt(v int) {
+	a.Add(float64(v))
+}
+
+// AddAll adds the values under a single lock
+func (a *Aggregator) AddAll(values ...float64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, v := range values {
+		a.add(v)
+	}
+}
+
+func (a *Aggregator) add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		a.skipped++
+		return
+	}
+	a.count++
+	a.addToSum(v, 0)
+	a.min = math.Min(a.min, v)
+	a.max = math.Max(a.max, v)
+	delta := v - a.mean
+	a.mean += delta / float64(a.count)
+	a.m2 += delta * (v - a.mean)
+	a.sketch.add(v, 1)
+}
+
+func (a *Aggregator) addToSum(v, compensation float64) {
+	t := a.sum + v
+	if math.Abs(a.sum) >= math.Abs(v) {
+		a.compensation += (a.sum - t) + v
+	} else {
+		a.compensation += (v - t) + a.sum
+	}
+	a.sum = t
+	a.compensation += compensation
+}
+
+/*
+Consume adds the values of the channel until it is closed. It returns ctx.Err() when the context is done first,
+the values already received stay in the aggregator
+*/
+func (a *Aggregator) Consume(ctx context.Context, values <-chan float64) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case v, ok := <-values:
+			if !ok {
+				return nil
+			}
+			a.Add(v)
+		}
+	}
+}
+
+// Merge adds everything other has seen to a. other is not changed
+func (a *Aggregator) Merge(other *Aggregator) error {
+	if a == other {
+		return fmt.Errorf("stats: cannot merge an aggregator with itself")
+	}
+	// copy other first, holding both locks could deadlock with other.Merge(a)
+	other.mutex.RLock()
+	o := other.state
+	o.sketch = other.sketch.clone()
+	other.mutex.RUnlock()
+
+	if o.accuracy != a.accuracy {
+		return fmt.Errorf("stats: cannot merge relative accuracy %v into %v", o.accuracy, a.accuracy)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.skipped += o.skipped
+	if o.count == 0 {
+		return nil
+	}
+	// Chan et al. combination of two means and m2
+	count := a.count + o.count
+	delta := o.mean - a.mean
+	a.mean += delta * float64(o.count) / float64(count)
+	a.m2 += o.m2 + delta*delta*float64(a.count)*float64(o.count)/float64(count)
+	a.count = count
+	a.addToSum(o.sum, o.compensation)
+	a.min = math.Min(a.min, o.min)
+	a.max = math.Max(a.max, o.max)
+	a.sketch.merge(o.sketch)
+	return nil
+}
+
+func (a *Aggregator) Count() int64 {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.count
+}
+
+/*
+Quantile returns the approximate value below which the fraction q of the values are, Quantile(0.5) is the median.
+It is 0 when nothing has been added
+*/
+func (a *Aggregator) Quantile(q float64) float64 {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.quantile(q)
+}
+
+func (a *Aggregator) quantile(q float64) float64 {
+	if a.count == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+	v := a.sketch.quantile(int64(q * float64(a.count-1)))
+	// the bucket value can be slightly outside of what was really seen
+	return math.Max(a.min, math.Min(a.max, v))
+}
+
+func (a *Aggregator) Summary() Summary {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	s := Summary{Count: a.count, Skipped: a.skipped}
+	if a.count == 0 {
+		return s
+	}
+	s.Sum = a.sum + a.compensation
+	s.Min, s.Max, s.Mean = a.min, a.max, a.mean
+	s.Variance = a.m2 / float64(a.count)
+	s.StdDev = math.Sqrt(s.Variance)
+	s.P50, s.P90, s.P95, s.P99 = a.quantile(0.5), a.quantile(0.9), a.quantile(0.95), a.quantile(0.99)
+	return s
+}
+
+// Reset forgets every value, the configuration is kept
+func (a *Aggregator) Reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.state = state{
+		accuracy: a.accuracy,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+		sketch:   newSketch(a.accuracy, a.sketch.maxBuckets),
+	}
+}
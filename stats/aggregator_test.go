@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+)
+
+func closeTo(got, want, relative float64) bool {
+	if want == 0 {
+		return math.Abs(got) <= relative
+	}
+	return math.Abs(got-want) <= relative*math.Abs(want)
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   Summary
+	}{
+		{"empty", nil, Summary{}},
+		{"one value", []float64{5}, Summary{Count: 1, Sum: 5, Min: 5, Max: 5, Mean: 5, P50: 5, P90: 5, P95: 5, P99: 5}},
+		{"population variance", []float64{2, 4, 4, 4, 5, 5, 7, 9},
+			Summary{Count: 8, Sum: 40, Min: 2, Max: 9, Mean: 5, Variance: 4, StdDev: 2, P50: 4, P90: 7, P95: 7, P99: 7}},
+		{"NaN and infinities are skipped", []float64{1, math.NaN(), math.Inf(1), 3, math.Inf(-1)},
+			Summary{Count: 2, Sum: 4, Min: 1, Max: 3, Mean: 2, Variance: 1, StdDev: 1, P50: 1, P90: 1, P95: 1, P99: 1, Skipped: 3}},
+		{"negative values", []float64{-10, -20, -30},
+			Summary{Count: 3, Sum: -60, Min: -30, Max: -10, Mean: -20, Variance: 200.0 / 3, StdDev: math.Sqrt(200.0 / 3), P50: -20, P90: -20, P95: -20, P99: -20}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			aggregator := New(Config{})
+			aggregator.AddAll(test.values...)
+			got := aggregator.Summary()
+			if got.Count != test.want.Count || got.Skipped != test.want.Skipped {
+				t.Fatalf("Count, Skipped = %d, %d, want %d, %d", got.Count, got.Skipped, test.want.Count, test.want.Skipped)
+			}
+			exact := []struct {
+				name      string
+				got, want float64
+			}{
+				{"Sum", got.Sum, test.want.Sum}, {"Min", got.Min, test.want.Min}, {"Max", got.Max, test.want.Max},
+				{"Mean", got.Mean, test.want.Mean}, {"Variance", got.Variance, test.want.Variance}, {"StdDev", got.StdDev, test.want.StdDev},
+			}
+			for _, e := range exact {
+				if !closeTo(e.got, e.want, 1e-12) {
+					t.Errorf("%v = %v, want %v", e.name, e.got, e.want)
+				}
+			}
+			approximate := []struct {
+				name      string
+				got, want float64
+			}{
+				{"P50", got.P50, test.want.P50}, {"P90", got.P90, test.want.P90}, {"P95", got.P95, test.want.P95}, {"P99", got.P99, test.want.P99},
+			}
+			for _, a := range approximate {
+				if !closeTo(a.got, a.want, DefaultRelativeAccuracy) {
+					t.Errorf("%v = %v, want %v within 1%%", a.name, a.got, a.want)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileAccuracy(t *testing.T) {
+	aggregator := New(Config{})
+	for v := 1; v <= 10000; v++ {
+		aggregator.AddInt(v)
+	}
+	for _, q := range []float64{0, 0.25, 0.5, 0.9, 0.99, 1} {
+		want := 1 + q*9999
+		if got := aggregator.Quantile(q); !closeTo(got, want, DefaultRelativeAccuracy) {
+			t.Errorf("Quantile(%v) = %v, want %v within 1%%", q, got, want)
+		}
+	}
+}
+
+// Sum is compensated: adding many small values to a big one does not lose them
+func TestCompensatedSum(t *testing.T) {
+	aggregator := New(Config{})
+	aggregator.Add(1e16)
+	for i := 0; i < 1000; i++ {
+		aggregator.Add(1)
+	}
+	aggregator.Add(-1e16)
+	if got := aggregator.Summary().Sum; got != 1000 {
+		t.Errorf("Sum = %v, want 1000", got)
+	}
+}
+
+// run with -race: workers with their own aggregator merged at the end give the same result as one aggregator
+func TestMergeOfConcurrentWorkers(t *testing.T) {
+	const workers = 4
+	single := New(Config{})
+	partials := make([]*Aggregator, workers)
+	channels := make([]chan float64, workers)
+	var wg sync.WaitGroup
+	for i := range partials {
+		partials[i] = New(Config{})
+		channels[i] = make(chan float64)
+		wg.Add(1)
+		go func(aggregator *Aggregator, values <-chan float64) {
+			defer wg.Done()
+			aggregator.Consume(context.Background(), values)
+		}(partials[i], channels[i])
+	}
+	for v := 1; v <= 1000; v++ {
+		single.AddInt(v)
+		channels[v%workers] <- float64(v)
+	}
+	for _, values := range channels {
+		close(values)
+	}
+	wg.Wait()
+
+	merged := New(Config{})
+	for _, partial := range partials {
+		if err := merged.Merge(partial); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, want := merged.Summary(), single.Summary()
+	if got.Count != want.Count || got.Sum != want.Sum || got.Min != want.Min || got.Max != want.Max || got.P50 != want.P50 || got.P99 != want.P99 {
+		t.Errorf("merged %+v, want %+v", got, want)
+	}
+	if !closeTo(got.Mean, want.Mean, 1e-12) || !closeTo(got.Variance, want.Variance, 1e-12) {
+		t.Errorf("merged mean %v variance %v, want %v %v", got.Mean, got.Variance, want.Mean, want.Variance)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	aggregator := New(Config{})
+	if err := aggregator.Merge(aggregator); err == nil {
+		t.Error("merging an aggregator with itself did not fail")
+	}
+	if err := aggregator.Merge(New(Config{RelativeAccuracy: 0.05})); err == nil {
+		t.Error("merging a different accuracy did not fail")
+	}
+}
+
+func TestConsumeStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := New(Config{}).Consume(ctx, make(chan float64)); err != context.Canceled {
+		t.Errorf("Consume() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestMaxBuckets(t *testing.T) {
+	aggregator := New(Config{MaxBuckets: 16})
+	for v := 1; v <= 100000; v *= 2 {
+		aggregator.AddInt(v)
+	}
+	aggregator.mutex.RLock()
+	buckets := len(aggregator.sketch.positive) + len(aggregator.sketch.negative)
+	aggregator.mutex.RUnlock()
+	if buckets > 16 {
+		t.Errorf("%d buckets, want at most 16", buckets)
+	}
+	// the collapsed buckets are the smallest values, the top is still accurate
+	if got := aggregator.Quantile(1); !closeTo(got, 65536, DefaultRelativeAccuracy) {
+		t.Errorf("Quantile(1) = %v, want 65536 within 1%%", got)
+	}
+}
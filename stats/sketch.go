@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+sketch keeps the approximate distribution for the percentiles. Every value falls in the bucket
+ceil(log_gamma(|v|)), so a bucket spans a fixed relative width and the value it reports is within the relative
+accuracy of the real one, whatever the range of the values. Two sketches with the same accuracy merge by
+adding their buckets. When there are more than maxBuckets, the smallest buckets are folded into the next one,
+which keeps the memory bounded and loses accuracy only on the smallest values
+*/
+type sketch struct {
+	gamma      float64
+	logGamma   float64
+	maxBuckets int
+	positive   map[int]int64
+	negative   map[int]int64
+	zeros      int64
+}
+
+func newSketch(accuracy float64, maxBuckets int) *sketch {
+	gamma := (1 + accuracy) / (1 - accuracy)
+	return &sketch{
+		gamma:      gamma,
+		logGamma:   math.Log(gamma),
+		maxBuckets: maxBuckets,
+		positive:   make(map[int]int64),
+		negative:   make(map[int]int64),
+	}
+}
+
+func (s *sketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value is the middle of the bucket in relative terms
+func (s *sketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+func (s *sketch) add(v float64, n int64) {
+	switch {
+	case v > 0:
+		s.positive[s.index(v)] += n
+	case v < 0:
+		s.negative[s.index(-v)] += n
+	default:
+		s.zeros += n
+	}
+	s.collapse()
+}
+
+func (s *sketch) merge(other *sketch) {
+	for i, n := range other.positive {
+		s.positive[i] += n
+	}
+	for i, n := range other.negative {
+		s.negative[i] += n
+	}
+	s.zeros += other.zeros
+	s.collapse()
+}
+
+// collapse folds the buckets closest to zero until the limit is respected
+func (s *sketch) collapse() {
+	for len(s.positive)+len(s.negative) > s.maxBuckets {
+		buckets := s.positive
+		if len(s.negative) > len(s.positive) {
+			buckets = s.negative
+		}
+		indexes := sortedIndexes(buckets)
+		buckets[indexes[1]] += buckets[indexes[0]]
+		delete(buckets, indexes[0])
+	}
+}
+
+func (s *sketch) clone() *sketch {
+	c := *s
+	c.positive = make(map[int]int64, len(s.positive))
+	for i, n := range s.positive {
+		c.positive[i] = n
+	}
+	c.negative = make(map[int]int64, len(s.negative))
+	for i, n := range s.negative {
+		c.negative[i] = n
+	}
+	return &c
+}
+
+// quantile returns the value of the given rank (0 is the smallest value) out of count values
+func (s *sketch) quantile(rank int64) float64 {
+	// from the most negative value up: negative buckets by decreasing magnitude, zeros, positive buckets
+	negative := sortedIndexes(s.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		rank -= s.negative[negative[i]]
+		if rank < 0 {
+			return -s.value(negative[i])
+		}
+	}
+	rank -= s.zeros
+	if rank < 0 {
+		return 0
+	}
+	positive := sortedIndexes(s.positive)
+	for _, i := range positive {
+		rank -= s.positive[i]
+		if rank < 0 {
+			return s.value(i)
+		}
+	}
+	if len(positive) > 0 {
+		return s.value(positive[len(positive)-1])
+	}
+	return 0
+}
+
+func sortedIndexes(buckets map[int]int64) []int {
+	indexes := make([]int, 0, len(buckets))
+	for i := range buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
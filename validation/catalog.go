@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	English = "en"
+	Greek   = "el"
+)
+
+/*
+Catalog maps a rule code to a message template. {field}, {code} and {params} are replaced by the values of
+the FieldError. The empty code is the fallback for rules without a message of their own
+*/
+type Catalog map[string]string
+
+var catalogs = struct {
+	sync.RWMutex
+	languages map[string]Catalog
+}{languages: map[string]Catalog{
+	English: {
+		"":         "{field} failed rule {code}",
+		"required": "{field} is required",
+		"min":      "{field} must be at least {params}",
+		"max":      "{field} must be at most {params}",
+		"len":      "{field} must have length {params}",
+		"oneof":    "{field} must be one of [{params}]",
+		"unique":   "{field} must not contain duplicates",
+	},
+	Greek: {
+		"":         "Το πεδίο {field} δεν ικανοποιεί τον κανόνα {code}",
+		"required": "Το πεδίο {field} είναι υποχρεωτικό",
+		"min":      "Το πεδίο {field} πρέπει να είναι τουλάχιστον {params}",
+		"max":      "Το πεδίο {field} πρέπει να είναι το πολύ {params}",
+		"len":      "Το πεδίο {field} πρέπει να έχει μήκος {params}",
+		"oneof":    "Το πεδίο {field} πρέπει να είναι ένα από [{params}]",
+		"unique":   "Το πεδίο {field} δεν πρέπει να περιέχει διπλότυπα",
+	},
+}}
+
+// AddMessages adds (or replaces) messages of a language, e.g. for the codes of custom rules
+func AddMessages(lang string, messages Catalog) {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+	lang = baseLanguage(lang)
+	catalog, ok := catalogs.languages[lang]
+	if !ok {
+		catalog = Catalog{}
+		catalogs.languages[lang] = catalog
+	}
+	for code, message := range messages {
+		catalog[code] = message
+	}
+}
+
+/*
+lookupMessage falls back from the language to English, and from the code to the generic message.
+"el-GR" uses the "el" catalog
+*/
+func lookupMessage(lang, code string) string {
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	for _, l := range []string{baseLanguage(lang), English} {
+		if message, ok := catalogs.languages[l][code]; ok {
+			return message
+		}
+	}
+	for _, l := range []string{baseLanguage(lang), English} {
+		if message, ok := catalogs.languages[l][""]; ok {
+			return message
+		}
+	}
+	return "{field} failed rule {code}"
+}
+
+/*
+MatchLanguage picks the best language we have a catalog for from an Accept-Language header,
+e.g. "el-GR,el;q=0.9,en;q=0.8". English is returned when nothing matches
+*/
+func MatchLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		c := candidate{lang: baseLanguage(fields[0]), quality: 1}
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if q, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					c.quality = q
+				}
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	for _, c := range candidates {
+		if _, ok := catalogs.languages[c.lang]; ok && c.quality > 0 {
+			return c.lang
+		}
+	}
+	return English
+}
+
+func baseLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
@@ -0,0 +1,86 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+FieldError is one failed rule on one field, in a shape an API can return as it is.
+Field is the path from the validated struct ("Animal.Name"), Code the rule ("max") and Params its
+parameters (["100"]). Message is filled in by Localize; until then Error falls back to English
+*/
+type FieldError struct {
+	Field   string      `json:"field"`
+	Code    string      `json:"code"`
+	Params  []string    `json:"params,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Value   interface{} `json:"-"`
+}
+
+func (fe *FieldError) Error() string {
+	if fe.Message != "" {
+		return fe.Message
+	}
+	return fe.Translate(English)
+}
+
+// Translate renders the message of the catalog for lang, see Catalog
+func (fe *FieldError) Translate(lang string) string {
+	template := lookupMessage(lang, fe.Code)
+	return strings.NewReplacer(
+		"{field}", fe.Field,
+		"{code}", fe.Code,
+		"{params}", strings.Join(fe.Params, ", "),
+	).Replace(template)
+}
+
+/*
+Errors holds every FieldError found by Validate. Handlers can find it in a wrapped error with errors.As
+and answer with StatusCode
+*/
+type Errors []*FieldError
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, fe := range errs {
+		messages[i] = fe.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// StatusCode is the HTTP status for a request that failed validation
+func (errs Errors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// As lets errors.As find the first *FieldError, for callers that only report one problem
+func (errs Errors) As(target interface{}) bool {
+	if fe, ok := target.(**FieldError); ok && len(errs) > 0 {
+		*fe = errs[0]
+		return true
+	}
+	return false
+}
+
+// Localize returns a copy of the errors with Message translated to lang
+func (errs Errors) Localize(lang string) Errors {
+	result := make(Errors, len(errs))
+	for i, fe := range errs {
+		localized := *fe
+		localized.Message = fe.Translate(lang)
+		result[i] = &localized
+	}
+	return result
+}
+
+// params splits the tag parameter: the values of oneof are separated by spaces, everything else is one value
+func params(code, param string) []string {
+	if param == "" {
+		return nil
+	}
+	if code == "oneof" {
+		return strings.Fields(param)
+	}
+	return []string{param}
+}
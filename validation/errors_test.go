@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	b := validBird()
+	b.Animal.Name = ""
+	err := Validate(b)
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Error() != "Animal.Name is required" {
+		t.Fatalf("first FieldError = %v", fe)
+	}
+	var errs Errors
+	errors.As(err, &errs)
+	if got := errs.Localize(Greek)[0].Message; got != "Το πεδίο Animal.Name είναι υποχρεωτικό" {
+		t.Errorf("Greek message = %q", got)
+	}
+	if errs[0].Message != "" {
+		t.Error("Localize changed the original errors")
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"el-GR,el;q=0.9,en;q=0.8", Greek},
+		{"fr-FR,en;q=0.5,el;q=0.7", Greek},
+		{"el;q=0,en", English},
+		{"de", English},
+	}
+	for _, test := range tests {
+		if got := MatchLanguage(test.header); got != test.want {
+			t.Errorf("MatchLanguage(%q) = %q, want %q", test.header, got, test.want)
+		}
+	}
+}
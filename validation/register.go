@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Func is a custom rule. param is whatever follows '=' in the tag, empty if there is nothing
+type Func func(field reflect.Value, param string) bool
+
+/*
+StructFunc validates a whole struct, for rules that involve more than one field. It receives the struct value
+(never a pointer) and returns the failures with Field relative to that struct, e.g. "SpeedKPH".
+Validate adds the path of the struct in front. Messages for new codes are added with AddMessages
+*/
+type StructFunc func(v interface{}) []*FieldError
+
+var registry = struct {
+	sync.RWMutex
+	structs map[reflect.Type][]StructFunc
+}{structs: map[reflect.Type][]StructFunc{}}
+
+// Register adds a named rule usable in tags. Built-in rules cannot be replaced and names are registered once
+func Register(name string, fn Func) error {
+	if name == "" || name == "omitempty" || name == "dive" {
+		return fmt.Errorf("validation: %q cannot be used as a rule name", name)
+	}
+	if fn == nil {
+		return errors.New("validation: nil rule")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, exists := rules[name]; exists {
+		return fmt.Errorf("validation: rule %q already registered", name)
+	}
+	rules[name] = func(value reflect.Value, param string) (bool, error) {
+		return fn(value, param), nil
+	}
+	return nil
+}
+
+// RegisterStruct runs fn every time a value of the same type as sample is validated, also when nested
+func RegisterStruct(sample interface{}, fn StructFunc) {
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.structs[t] = append(registry.structs[t], fn)
+}
+
+func lookupRule(name string) (ruleFunc, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+func structFuncs(t reflect.Type) []StructFunc {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.structs[t]
+}
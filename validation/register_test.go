@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type trip struct {
+	From     string `validate:"required,capitalized"`
+	To       string `validate:"capitalized"`
+	SpeedKPH int
+	MaxKPH   int
+}
+
+func init() {
+	err := Register("capitalized", func(field reflect.Value, param string) bool {
+		text := field.String()
+		return text == "" || strings.ToUpper(text[:1]) == text[:1]
+	})
+	if err != nil {
+		panic(err)
+	}
+	RegisterStruct(trip{}, func(v interface{}) []*FieldError {
+		t := v.(trip)
+		if t.SpeedKPH > t.MaxKPH {
+			return []*FieldError{{Field: "SpeedKPH", Code: "ltefield", Params: []string{"MaxKPH"}}}
+		}
+		return nil
+	})
+}
+
+type journey struct {
+	Legs []trip `validate:"dive"`
+}
+
+func TestCustomAndCrossFieldValidators(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"valid", trip{From: "Athens", To: "Rome", SpeedKPH: 80, MaxKPH: 100}, nil},
+		{"custom rule", trip{From: "athens", MaxKPH: 100}, []string{"From:capitalized"}},
+		{"cross field", trip{From: "Athens", SpeedKPH: 120, MaxKPH: 100}, []string{"SpeedKPH:ltefield"}},
+		{"struct rules run after the fields", trip{From: "athens", SpeedKPH: 1}, []string{"From:capitalized", "SpeedKPH:ltefield"}},
+		{"nested struct gets its path", journey{Legs: []trip{{From: "Athens"}, {From: "Rome", SpeedKPH: 5}}}, []string{"Legs[1].SpeedKPH:ltefield"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(test.value)
+			if got := fields(err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Validate() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	valid := func(field reflect.Value, param string) bool { return true }
+	tests := []struct {
+		name string
+		rule string
+		fn   Func
+	}{
+		{"built-in rule", "required", valid},
+		{"registered twice", "capitalized", valid},
+		{"reserved word", "dive", valid},
+		{"empty name", "", valid},
+		{"nil rule", "nothing", nil},
+	}
+	for _, test := range tests {
+		if err := Register(test.rule, test.fn); err == nil {
+			t.Errorf("%v: Register(%q) did not fail", test.name, test.rule)
+		}
+	}
+}
+
+func TestCustomMessage(t *testing.T) {
+	AddMessages("el-GR", Catalog{"capitalized": "Το πεδίο {field} πρέπει να ξεκινά με κεφαλαίο"})
+	fe := &FieldError{Field: "From", Code: "capitalized"}
+	if got := fe.Translate(Greek); got != "Το πεδίο From πρέπει να ξεκινά με κεφαλαίο" {
+		t.Errorf("Translate(Greek) = %q", got)
+	}
+	if got := fe.Translate(English); got != "From failed rule capitalized" {
+		t.Errorf("Translate(English) = %q, want the generic message", got)
+	}
+}
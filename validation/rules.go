@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ruleFunc reports whether value passes the rule. An error means the param of the tag is invalid
+type ruleFunc func(value reflect.Value, param string) (bool, error)
+
+var rules = map[string]ruleFunc{
+	"required": required,
+	"min":      minimum,
+	"max":      maximum,
+	"len":      length,
+	"oneof":    oneOf,
+	"unique":   unique,
+}
+
+func required(value reflect.Value, param string) (bool, error) {
+	return !isEmpty(value), nil
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Chan, reflect.String:
+		return value.Len() == 0
+	case reflect.Array: //the length of an array is part of its type, only its elements can be empty
+		return value.IsZero()
+	case reflect.Invalid:
+		return true
+	}
+	return value.IsZero()
+}
+
+func minimum(value reflect.Value, param string) (bool, error) {
+	size, limit, err := measure(value, param)
+	return size >= limit, err
+}
+
+func maximum(value reflect.Value, param string) (bool, error) {
+	size, limit, err := measure(value, param)
+	return size <= limit, err
+}
+
+func length(value reflect.Value, param string) (bool, error) {
+	size, limit, err := measure(value, param)
+	return size == limit, err
+}
+
+/*
+measure returns the number a limit is compared with: the value itself for numbers, the number of
+characters for strings and the number of elements for collections
+*/
+func measure(value reflect.Value, param string) (float64, float64, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a number", param)
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), limit, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), limit, nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), limit, nil
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), limit, nil
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Chan:
+		return float64(value.Len()), limit, nil
+	case reflect.Ptr:
+		if value.IsNil() {
+			return 0, limit, nil
+		}
+		return measure(value.Elem(), param)
+	}
+	return 0, 0, fmt.Errorf("cannot measure a %v", value.Type())
+}
+
+// oneOf takes the allowed values separated by spaces: `validate:"oneof=Europe Asia"`
+func oneOf(value reflect.Value, param string) (bool, error) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return false, nil
+		}
+		value = value.Elem()
+	}
+	actual := fmt.Sprint(value.Interface())
+	for _, allowed := range strings.Fields(param) {
+		if actual == allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// unique is true when no two elements of a slice or array (or values of a map) are equal
+func unique(value reflect.Value, param string) (bool, error) {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		seen := make(map[string]bool, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			key := fmt.Sprintf("%#v", value.Index(i))
+			if seen[key] {
+				return false, nil
+			}
+			seen[key] = true
+		}
+		return true, nil
+	case reflect.Map:
+		seen := make(map[string]bool, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%#v", iter.Value())
+			if seen[key] {
+				return false, nil
+			}
+			seen[key] = true
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("cannot check a %v for duplicates", value.Type())
+}
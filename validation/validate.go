@@ -0,0 +1,243 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+/*
+Validate checks every field of a struct (or pointer to struct) against its `validate` tag, for example
+`validate:"required,max=100"`. Nested and embedded structs are walked too, so a Bird reports "Animal.Name".
+The rules after "dive" apply to each element of a slice, array or map: `validate:"unique,dive,required"`
+reports "Companions[1]". Struct validators added with RegisterStruct run after the fields of their struct.
+All failing fields are returned together as Errors. Any other error means a tag could not be understood
+*/
+func Validate(v interface{}) error {
+	w := &walker{visiting: map[pointer]bool{}}
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return errors.New("validation: nil pointer")
+		}
+		w.visiting[pointer{address: value.Pointer(), t: value.Type()}] = true
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validation: %T is not a struct", v)
+	}
+	if err := w.validateStruct(value, ""); err != nil {
+		return err
+	}
+	if len(w.errs) == 0 {
+		return nil
+	}
+	return w.errs
+}
+
+/*
+walker collects the errors of one Validate call. visiting holds the pointers being walked into, so a struct
+that points back at itself (a list whose last node links to the first) is walked once instead of forever
+*/
+type walker struct {
+	errs     Errors
+	visiting map[pointer]bool
+}
+
+// pointer identifies what a pointer refers to. A struct and its first field share the address, not the type
+type pointer struct {
+	address uintptr
+	t       reflect.Type
+}
+
+func (w *walker) validateStruct(value reflect.Value, prefix string) error {
+	fields, err := cachedFields(value.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		fieldValue := value.Field(f.index)
+		path := prefix + f.name
+		if err := w.validateField(fieldValue, path, f.checks); err != nil {
+			return err
+		}
+		if err := w.validateNested(fieldValue, path); err != nil {
+			return err
+		}
+	}
+	if !value.CanInterface() {
+		return nil
+	}
+	for _, fn := range structFuncs(value.Type()) {
+		for _, fe := range fn(value.Interface()) {
+			fe.Field = prefix + fe.Field
+			w.errs = append(w.errs, fe)
+		}
+	}
+	return nil
+}
+
+// validateNested walks into a struct held by value, directly or through pointers
+func (w *walker) validateNested(value reflect.Value, path string) error {
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		p := pointer{address: value.Pointer(), t: value.Type()}
+		if w.visiting[p] {
+			return nil
+		}
+		w.visiting[p] = true
+		defer delete(w.visiting, p)
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	return w.validateStruct(value, path+".")
+}
+
+func (w *walker) validateField(value reflect.Value, path string, cs checks) error {
+	for _, c := range cs.list {
+		if c.name == "omitempty" {
+			if isEmpty(value) {
+				return nil
+			}
+			continue
+		}
+		ok, err := c.rule(value, c.param)
+		if err != nil {
+			return fmt.Errorf("validation: %v: rule %q: %v", path, c.name, err)
+		}
+		if !ok {
+			w.errs = append(w.errs, &FieldError{Field: path, Code: c.name, Params: params(c.name, c.param), Value: valueOf(value)})
+		}
+	}
+	if cs.dive == nil {
+		return nil
+	}
+	return w.dive(value, path, *cs.dive)
+}
+
+// dive applies the element checks to every element, and walks the elements that are structs
+func (w *walker) dive(value reflect.Value, path string, cs checks) error {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			elementPath := fmt.Sprintf("%v[%d]", path, i)
+			if err := w.validateField(value.Index(i), elementPath, cs); err != nil {
+				return err
+			}
+			if err := w.validateNested(value.Index(i), elementPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			elementPath := fmt.Sprintf("%v[%v]", path, iter.Key())
+			if err := w.validateField(iter.Value(), elementPath, cs); err != nil {
+				return err
+			}
+			if err := w.validateNested(iter.Value(), elementPath); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("validation: %v: dive needs a slice, array or map, not %v", path, value.Type())
+	}
+	return nil
+}
+
+func valueOf(value reflect.Value) interface{} {
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	return nil
+}
+
+// check is one parsed entry of a tag: "max=100" is check{name: "max", param: "100"}
+type check struct {
+	name  string
+	param string
+	rule  ruleFunc
+}
+
+// checks of a field. dive holds the checks of the elements, if the tag has a "dive"
+type checks struct {
+	list []check
+	dive *checks
+}
+
+type field struct {
+	index  int
+	name   string
+	checks checks
+}
+
+// the tags of a type never change, so they are parsed once per type
+var fieldCache sync.Map
+
+func cachedFields(t reflect.Type) ([]field, error) {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field), nil
+	}
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { //unexported
+			continue
+		}
+		tag := sf.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		checks, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("validation: %v.%v: %v", t.Name(), sf.Name, err)
+		}
+		fields = append(fields, field{index: i, name: sf.Name, checks: checks})
+	}
+	fieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func parseTag(tag string) (checks, error) {
+	return parseChecks(strings.Split(tag, ","))
+}
+
+func parseChecks(parts []string) (checks, error) {
+	var cs checks
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part == "dive" {
+			elements, err := parseChecks(parts[i+1:])
+			if err != nil {
+				return checks{}, err
+			}
+			cs.dive = &elements
+			return cs, nil
+		}
+		c := check{name: part}
+		if i := strings.Index(part, "="); i >= 0 {
+			c.name, c.param = part[:i], part[i+1:]
+		}
+		if c.name != "omitempty" {
+			rule, ok := lookupRule(c.name)
+			if !ok {
+				return checks{}, fmt.Errorf("unknown rule %q", c.name)
+			}
+			c.rule = rule
+		}
+		cs.list = append(cs.list, c)
+	}
+	return cs, nil
+}
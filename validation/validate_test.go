@@ -0,0 +1,167 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type animal struct {
+	Name   string `validate:"required,max=10"`
+	Origin string `validate:"omitempty,oneof=Europe Asia"`
+}
+
+type bird struct {
+	animal
+	Animal     animal
+	SpeedKPH   float64           `validate:"min=0,max=300"`
+	Companions []string          `validate:"unique,max=3,dive,required"`
+	Tags       map[string]string `validate:"dive,len=2"`
+	Mother     *animal
+	ignored    string
+}
+
+func validBird() bird {
+	return bird{
+		animal:     animal{Name: "Emu"},
+		Animal:     animal{Name: "Kiwi", Origin: "Asia"},
+		SpeedKPH:   50,
+		Companions: []string{"Mike", "Jim"},
+		Tags:       map[string]string{"a": "ok"},
+	}
+}
+
+// fields returns "Field:code" of every FieldError, in order
+func fields(err error) []string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	var result []string
+	for _, fe := range errs {
+		result = append(result, fe.Field+":"+fe.Code)
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(b *bird)
+		want   []string
+	}{
+		{"valid", func(b *bird) {}, nil},
+		{"required and max", func(b *bird) { b.Animal.Name = "" }, []string{"Animal.Name:required"}},
+		{"max counts characters", func(b *bird) { b.Animal.Name = "Καλημέρααα" }, nil},
+		{"embedded struct", func(b *bird) { b.animal.Name = strings.Repeat("x", 11) }, []string{"animal.Name:max"}},
+		{"oneof", func(b *bird) { b.Animal.Origin = "Mars" }, []string{"Animal.Origin:oneof"}},
+		{"min and max of numbers", func(b *bird) { b.SpeedKPH = -1 }, []string{"SpeedKPH:min"}},
+		{"unique and dive", func(b *bird) { b.Companions = []string{"Jim", "", "Jim"} },
+			[]string{"Companions:unique", "Companions[1]:required"}},
+		{"max of a slice", func(b *bird) { b.Companions = []string{"a", "b", "c", "d"} }, []string{"Companions:max"}},
+		{"dive into a map", func(b *bird) { b.Tags = map[string]string{"x": "long"} }, []string{"Tags[x]:len"}},
+		{"nested pointer", func(b *bird) { b.Mother = &animal{} }, []string{"Mother.Name:required"}},
+		{"everything at once", func(b *bird) {
+			b.Animal.Name = ""
+			b.SpeedKPH = 301
+		}, []string{"Animal.Name:required", "SpeedKPH:max"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := validBird()
+			test.change(&b)
+			err := Validate(&b)
+			if got := fields(err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Validate() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		valid bool
+	}{
+		{"empty string", "", false},
+		{"string", "a", true},
+		{"zero number", 0, false},
+		{"nil slice", []int(nil), false},
+		{"empty slice", []int{}, false},
+		{"slice of zeros", []int{0}, true},
+		{"empty map", map[string]int{}, false},
+		{"zero array", [2]int{}, false},
+		{"array", [2]int{0, 1}, true},
+		{"empty array", [0]int{}, false},
+		{"nil pointer", (*int)(nil), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, _ := required(reflect.ValueOf(test.value), ""); got != test.valid {
+				t.Errorf("required(%#v) = %v, want %v", test.value, got, test.valid)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	var nilBird *bird
+	type badTag struct {
+		Name string `validate:"required,shiny"`
+	}
+	type badParam struct {
+		Name string `validate:"max=ten"`
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"nil pointer", nilBird},
+		{"not a struct", 3},
+		{"unknown rule", badTag{}},
+		{"invalid parameter", badParam{Name: "x"}},
+	}
+	for _, test := range tests {
+		err := Validate(test.value)
+		var errs Errors
+		if err == nil || errors.As(err, &errs) {
+			t.Errorf("%v: Validate() = %v, want an error that is not Errors", test.name, err)
+		}
+	}
+}
+
+type node struct {
+	Name     string `validate:"required"`
+	Next     *node
+	Children []*node `validate:"dive"`
+}
+
+func TestValidateCycles(t *testing.T) {
+	loop := &node{Name: "a"}
+	loop.Next = &node{Next: loop}
+	self := &node{}
+	self.Next = self
+	self.Children = []*node{self}
+	shared := &node{}
+	tree := node{Name: "root", Next: shared, Children: []*node{shared}}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"two nodes in a loop", loop, []string{"Next.Name:required"}},
+		{"a node pointing at itself", self, []string{"Name:required"}},
+		{"a node reached twice is not a cycle", tree, []string{"Next.Name:required", "Children[0].Name:required"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(test.value)
+			if got := fields(err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Validate() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
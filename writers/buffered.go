@@ -0,0 +1,76 @@
+package writers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned by writes after Close
+var ErrClosed = errors.New("writers: write after close")
+
+/*
+Buffered collects small writes and passes them to the underlying Writer in blocks of Size bytes.
+Nothing reaches the underlying writer before the buffer is full, Flush or Close: call Flush when the data has to
+be visible (at the end of a request, before exiting...). It is safe across goroutines
+*/
+type Buffered struct {
+	mutex  sync.Mutex
+	out    Writer
+	buffer *bufio.Writer
+	closed bool
+}
+
+// DefaultBufferSize is used when NewBuffered gets size <= 0
+const DefaultBufferSize = 4096
+
+func NewBuffered(out Writer, size int) *Buffered {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Buffered{out: out, buffer: bufio.NewWriterSize(out, size)}
+}
+
+func (b *Buffered) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return 0, ErrClosed
+	}
+	return b.buffer.Write(data)
+}
+
+// Flush writes the buffered data. After a failed write the error is returned by every later call
+func (b *Buffered) Flush() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	return b.buffer.Flush()
+}
+
+// Buffered returns the number of bytes waiting for a Flush
+func (b *Buffered) Buffered() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Buffered()
+}
+
+// Close flushes and closes the underlying writer when it is an io.Closer. Closing twice is a no op
+func (b *Buffered) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	err := b.buffer.Flush()
+	if closer, ok := b.out.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
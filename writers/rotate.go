@@ -0,0 +1,157 @@
+package writers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type RotatingFileConfig struct {
+	Path string
+	// MaxBytes rotates before a write that would make the file bigger. 0 means no size limit
+	MaxBytes int64
+	// MaxAge rotates before the first write after the file has been open for that long. 0 means no age limit
+	MaxAge time.Duration
+	// MaxBackups is the number of old files kept as Path.1 (the newest) to Path.N. 0 keeps none
+	MaxBackups int
+}
+
+/*
+RotatingFile appends to a file and rotates it by size or age: Path is renamed to Path.1, Path.1 to Path.2
+and so on. A single write is never split between two files, so a write bigger than MaxBytes gets a file
+of its own. The age counts from when the file was opened, a restart starts it again.
+When a rotation fails the writes go on to the current file and the rotation is tried again after a minute
+*/
+type RotatingFile struct {
+	mutex   sync.Mutex
+	config  RotatingFileConfig
+	file    *os.File
+	size    int64
+	opened  time.Time
+	retryAt time.Time //no rotation is tried before, set when one fails
+	now     func() time.Time
+}
+
+// rotateRetryDelay is how long Write waits after a failed rotation before trying again
+const rotateRetryDelay = time.Minute
+
+// NewRotatingFile opens (or creates) the file for appending
+func NewRotatingFile(config RotatingFileConfig) (*RotatingFile, error) {
+	rf := &RotatingFile{config: config, now: time.Now}
+	file, size, err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+	rf.use(file, size)
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() (*os.File, int64, error) {
+	file, err := os.OpenFile(rf.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+func (rf *RotatingFile) use(file *os.File, size int64) {
+	rf.file = file
+	rf.size = size
+	rf.opened = rf.now()
+}
+
+func (rf *RotatingFile) Write(data []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.file == nil {
+		return 0, ErrClosed
+	}
+	var rotateErr error
+	if rf.size > 0 && rf.needsRotation(int64(len(data))) && !rf.now().Before(rf.retryAt) {
+		//a failed rotation is reported once, the data still goes to the current file
+		if rotateErr = rf.rotate(); rotateErr != nil {
+			rf.retryAt = rf.now().Add(rotateRetryDelay)
+		}
+	}
+	n, err := rf.file.Write(data)
+	rf.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (rf *RotatingFile) needsRotation(next int64) bool {
+	if rf.config.MaxBytes > 0 && rf.size+next > rf.config.MaxBytes {
+		return true
+	}
+	return rf.config.MaxAge > 0 && rf.now().Sub(rf.opened) >= rf.config.MaxAge
+}
+
+// Rotate starts a new file now, whatever its size and age
+func (rf *RotatingFile) Rotate() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.file == nil {
+		return ErrClosed
+	}
+	return rf.rotate()
+}
+
+/*
+rotate moves the files and opens the new one before closing the old one. Until both have succeeded rf.file stays
+the old file, which keeps every later write working: after a failed rename it is still Path, after a failed open
+it is Path.1
+*/
+func (rf *RotatingFile) rotate() error {
+	path := rf.config.Path
+	var err error
+	if rf.config.MaxBackups > 0 {
+		os.Remove(fmt.Sprintf("%v.%d", path, rf.config.MaxBackups))
+		for i := rf.config.MaxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%v.%d", path, i), fmt.Sprintf("%v.%d", path, i+1))
+		}
+		err = os.Rename(path, path+".1")
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return err
+	}
+	file, size, err := rf.open()
+	if err != nil {
+		return err
+	}
+	old := rf.file
+	rf.use(file, size)
+	rf.retryAt = time.Time{}
+	return old.Close()
+}
+
+// Sync commits the file to disk
+func (rf *RotatingFile) Sync() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.file == nil {
+		return ErrClosed
+	}
+	return rf.file.Sync()
+}
+
+// Close closes the file. Closing twice is a no op
+func (rf *RotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
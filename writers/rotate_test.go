@@ -0,0 +1,136 @@
+package writers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func tempPath(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "app.log")
+}
+
+// readFiles returns the content of path, path.1 ... path.n, "" for a missing file
+func readFiles(path string, n int) []string {
+	result := make([]string, n+1)
+	for i := range result {
+		name := path
+		if i > 0 {
+			name = path + "." + string(rune('0'+i))
+		}
+		data, _ := ioutil.ReadFile(name)
+		result[i] = string(data)
+	}
+	return result
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBackups int
+		writes     []string
+		files      []string
+	}{
+		{"fits", 2, []string{"abc", "def"}, []string{"abcdef", "", ""}},
+		{"exactly MaxBytes", 2, []string{"abcd", "efgh"}, []string{"abcdefgh", "", ""}},
+		{"rotates before going over", 2, []string{"abcde", "fghij", "kl"}, []string{"fghijkl", "abcde", ""}},
+		{"keeps MaxBackups", 2, []string{"aaaaa", "bbbbb", "ccccc", "ddddd"}, []string{"ddddd", "ccccc", "bbbbb"}},
+		{"no backups", 0, []string{"aaaaa", "bbbbb", "ccccc"}, []string{"ccccc", "", ""}},
+		{"big write gets its own file", 1, []string{"ab", "0123456789", "cd"}, []string{"cd", "0123456789"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := tempPath(t)
+			file, err := NewRotatingFile(RotatingFileConfig{Path: path, MaxBytes: 8, MaxBackups: test.maxBackups})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range test.writes {
+				if n, err := file.Write([]byte(data)); err != nil || n != len(data) {
+					t.Fatalf("Write(%q) = %d, %v", data, n, err)
+				}
+			}
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got := readFiles(path, len(test.files)-1)
+			for i := range got {
+				if got[i] != test.files[i] {
+					t.Errorf("file %d = %q, want %q", i, got[i], test.files[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingFileByAge(t *testing.T) {
+	path := tempPath(t)
+	file, err := NewRotatingFile(RotatingFileConfig{Path: path, MaxAge: 20 * time.Millisecond, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.Write([]byte("old"))
+	time.Sleep(30 * time.Millisecond)
+	file.Write([]byte("new"))
+	if got := readFiles(path, 1); got[0] != "new" || got[1] != "old" {
+		t.Errorf("files = %q, want new and old", got)
+	}
+}
+
+func TestRotatingFileRetriesAFailedRotation(t *testing.T) {
+	path := tempPath(t)
+	// a non empty directory where the backup should go makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := NewRotatingFile(RotatingFileConfig{Path: path, MaxBytes: 8, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	now := time.Now()
+	file.now = func() time.Time { return now }
+
+	file.Write([]byte("abcdefgh"))
+	if n, err := file.Write([]byte("ij")); err == nil || n != 2 {
+		t.Errorf("Write() = %d, %v, want the data written and the rotation error", n, err)
+	}
+	if _, err := file.Write([]byte("kl")); err != nil {
+		t.Errorf("Write() during the back off error = %v, want nil", err)
+	}
+	if got := readFiles(path, 0); got[0] != "abcdefghijkl" {
+		t.Errorf("file = %q, want every write appended", got[0])
+	}
+
+	os.RemoveAll(path + ".1")
+	now = now.Add(rotateRetryDelay)
+	if _, err := file.Write([]byte("mn")); err != nil {
+		t.Fatalf("Write() after the back off error = %v", err)
+	}
+	if got := readFiles(path, 1); got[0] != "mn" || got[1] != "abcdefghijkl" {
+		t.Errorf("files = %q, want the rotation retried", got)
+	}
+}
+
+func TestRotatingFileAfterClose(t *testing.T) {
+	file, err := NewRotatingFile(RotatingFileConfig{Path: tempPath(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if _, err := file.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write() after Close error = %v, want ErrClosed", err)
+	}
+	if err := file.Rotate(); err != ErrClosed {
+		t.Errorf("Rotate() after Close error = %v, want ErrClosed", err)
+	}
+}
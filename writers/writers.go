@@ -0,0 +1,169 @@
+package writers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+/*
+Writer is the interface of main, the same method as io.Writer. main's ConsoleWriter used fmt.Println, which added
+a newline and counted it. Every writer here follows the io.Writer contract: Write writes all of data or returns
+an error, and the count is the number of bytes of data that were written, never more
+*/
+type Writer interface {
+	Write(data []byte) (int, error)
+}
+
+// Console writes data unchanged to Out, os.Stdout when Out is nil. The zero value is ready to use
+type Console struct {
+	Out io.Writer
+}
+
+func (c Console) Write(data []byte) (int, error) {
+	if c.Out == nil {
+		return os.Stdout.Write(data)
+	}
+	return c.Out.Write(data)
+}
+
+/*
+Multi writes every call to all of its writers, in order. Unlike io.MultiWriter it does not stop at the first
+failure: the other writers still get the data and the failures are returned together in a MultiError,
+with a count of 0 because the data was not written everywhere
+*/
+type Multi struct {
+	writers []Writer
+}
+
+func NewMulti(writers ...Writer) *Multi {
+	return &Multi{writers: append([]Writer(nil), writers...)}
+}
+
+func (m *Multi) Write(data []byte) (int, error) {
+	var failures MultiError
+	for i, w := range m.writers {
+		n, err := w.Write(data)
+		if err == nil && n != len(data) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			failures = append(failures, WriterError{Index: i, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return 0, failures
+	}
+	return len(data), nil
+}
+
+// Close closes the writers that are io.Closer and returns their failures together
+func (m *Multi) Close() error {
+	var failures MultiError
+	for i, w := range m.writers {
+		if closer, ok := w.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				failures = append(failures, WriterError{Index: i, Err: err})
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// WriterError is the failure of the writer at Index of a Multi
+type WriterError struct {
+	Index int
+	Err   error
+}
+
+func (e WriterError) Error() string {
+	return fmt.Sprintf("writer %d: %v", e.Index, e.Err)
+}
+
+func (e WriterError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError lists the writers of a Multi that failed. errors.Is and errors.As look into every one of them
+type MultiError []WriterError
+
+func (e MultiError) Error() string {
+	messages := make([]string, len(e))
+	for i, failure := range e {
+		messages[i] = failure.Error()
+	}
+	return "writers: " + strings.Join(messages, "; ")
+}
+
+func (e MultiError) Is(target error) bool {
+	for _, failure := range e {
+		if errors.Is(failure.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e MultiError) As(target interface{}) bool {
+	for _, failure := range e {
+		if errors.As(failure.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Memory keeps everything written in memory, for tests and examples. It is safe across goroutines.
+The zero value is ready to use
+*/
+type Memory struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+	writes [][]byte
+}
+
+func (m *Memory) Write(data []byte) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.writes = append(m.writes, append([]byte(nil), data...))
+	return m.buffer.Write(data)
+}
+
+// Bytes returns a copy of everything written so far
+func (m *Memory) Bytes() []byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]byte(nil), m.buffer.Bytes()...)
+}
+
+func (m *Memory) String() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.buffer.String()
+}
+
+// Writes returns a copy of the data of every Write call, to check how the output was split
+func (m *Memory) Writes() [][]byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	writes := make([][]byte, len(m.writes))
+	for i, data := range m.writes {
+		writes[i] = append([]byte(nil), data...)
+	}
+	return writes
+}
+
+func (m *Memory) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.buffer.Reset()
+	m.writes = nil
+}
@@ -0,0 +1,125 @@
+package writers
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// failing fails every write with err
+type failing struct {
+	err error
+}
+
+func (f failing) Write(data []byte) (int, error) {
+	return 0, f.err
+}
+
+// short writes one byte less than asked, without an error
+type short struct{}
+
+func (short) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	return len(data) - 1, nil
+}
+
+func TestMulti(t *testing.T) {
+	errDisk := errors.New("disk full")
+	tests := []struct {
+		name     string
+		writers  func(first, last *Memory) []Writer
+		wantN    int
+		failures []int
+	}{
+		{"all succeed", func(first, last *Memory) []Writer { return []Writer{first, last} }, 5, nil},
+		{"failure in the middle", func(first, last *Memory) []Writer { return []Writer{first, failing{errDisk}, last} }, 0, []int{1}},
+		{"short write", func(first, last *Memory) []Writer { return []Writer{short{}, first, last} }, 0, []int{0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var first, last Memory
+			n, err := NewMulti(test.writers(&first, &last)...).Write([]byte("hello"))
+			if n != test.wantN {
+				t.Errorf("Write() = %d, want %d", n, test.wantN)
+			}
+			if first.String() != "hello" || last.String() != "hello" {
+				t.Errorf("the other writers got %q and %q, want hello", first.String(), last.String())
+			}
+			var failures MultiError
+			if test.failures == nil {
+				if err != nil {
+					t.Errorf("Write() error = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &failures) {
+				t.Fatalf("Write() error = %v, want a MultiError", err)
+			}
+			var indexes []int
+			for _, failure := range failures {
+				indexes = append(indexes, failure.Index)
+			}
+			if !reflect.DeepEqual(indexes, test.failures) {
+				t.Errorf("failed writers %v, want %v", indexes, test.failures)
+			}
+		})
+	}
+}
+
+func TestMultiErrorIs(t *testing.T) {
+	_, err := NewMulti(failing{os.ErrPermission}, failing{ErrClosed}).Write([]byte("x"))
+	if !errors.Is(err, os.ErrPermission) || !errors.Is(err, ErrClosed) {
+		t.Errorf("errors.Is does not see both failures in %v", err)
+	}
+}
+
+func TestBuffered(t *testing.T) {
+	var out Memory
+	buffered := NewBuffered(&out, 8)
+	buffered.Write([]byte("abc"))
+	buffered.Write([]byte("def"))
+	if out.String() != "" || buffered.Buffered() != 6 {
+		t.Fatalf("before the buffer is full: out %q, buffered %d", out.String(), buffered.Buffered())
+	}
+	buffered.Write([]byte("ghi"))
+	if got := out.String(); got != "abcdefgh" {
+		t.Errorf("after overflowing the buffer out = %q, want abcdefgh", got)
+	}
+	if err := buffered.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "abcdefghi" {
+		t.Errorf("after Close out = %q, want abcdefghi", got)
+	}
+	if _, err := buffered.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write() after Close error = %v, want ErrClosed", err)
+	}
+	if err := buffered.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
+
+// run with -race
+func TestBufferedConcurrentWrites(t *testing.T) {
+	var out Memory
+	buffered := NewBuffered(&out, 16)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				buffered.Write([]byte("0123456789"))
+			}
+		}()
+	}
+	wg.Wait()
+	buffered.Flush()
+	if got := len(out.Bytes()); got != 8*100*10 {
+		t.Errorf("%d bytes written, want %d", got, 8*100*10)
+	}
+}